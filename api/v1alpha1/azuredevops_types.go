@@ -0,0 +1,18 @@
+package v1alpha1
+
+type AzureDevOps struct {
+
+	// Azure DevOps Services or Server base url
+	// +kubebuilder:default="https://dev.azure.com"
+	// +kubebuilder:validation:Optional
+	Url string `json:"url,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Organization string `json:"organization"`
+
+	// +kubebuilder:validation:Required
+	Project string `json:"project"`
+
+	// +kubebuilder:validation:Required
+	Repository string `json:"repository"`
+}
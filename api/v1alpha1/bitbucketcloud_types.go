@@ -0,0 +1,16 @@
+package v1alpha1
+
+type BitbucketCloud struct {
+
+	// Bitbucket Cloud REST API base url
+	// +kubebuilder:default="https://api.bitbucket.org/2.0"
+	// +kubebuilder:validation:Optional
+	Url string `json:"url,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Workspace string `json:"workspace"`
+
+	// Repository slug
+	// +kubebuilder:validation:Required
+	Repository string `json:"repository"`
+}
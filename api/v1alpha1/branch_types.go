@@ -5,6 +5,30 @@ type Branch struct {
 	SHA     string `json:"sha,omitempty"`
 	Commit  string `json:"commit,omitempty"`
 	Details string `json:"details,omitempty"`
+
+	// Identifier of the pull request at the git provider, e.g. the pull request number
+	ID string `json:"id,omitempty"`
+
+	// Target branch of the pull request, which is only used to filter the pull requests of a repository and is not
+	// part of the status
+	TargetBranch string `json:"-"`
+	// The pull request is a draft, which is not ready for review
+	Draft bool `json:"draft,omitempty"`
+	// The pull request is opened from a fork of the repository
+	Fork bool `json:"fork,omitempty"`
+	// User name of the author of the pull request, if the git provider reports it
+	Author string `json:"author,omitempty"`
+	// Labels of the pull request, which are only used to filter the pull requests and are not part of the status
+	Labels []string `json:"-"`
+}
+
+// TargetBranch is the branch the pull requests are opened to. It only holds the fields of the spec, the fields of
+// the pull requests are part of the status.
+type TargetBranch struct {
+	Name    string `json:"name"`
+	SHA     string `json:"sha,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Details string `json:"details,omitempty"`
 }
 
 func (currentBranch *Branch) Equals(newBranch Branch) bool {
@@ -14,3 +38,30 @@ func (currentBranch *Branch) Equals(newBranch Branch) bool {
 		return false
 	}
 }
+
+// WithoutDetails returns a copy of the branch without the details. The details are only stored with the snapshot
+// of the source branches, the other lists of the status reference the pull request by its key and commit.
+func (currentBranch *Branch) WithoutDetails() Branch {
+	branch := *currentBranch
+	branch.Details = ""
+	return branch
+}
+
+// Key identifies the pull request of the branch. Branch names are not unique, e.g. for pull requests from forks,
+// therefore the pull request ID is preferred.
+func (currentBranch *Branch) Key() string {
+	if len(currentBranch.ID) > 0 {
+		return currentBranch.ID
+	}
+	return currentBranch.Name
+}
+
+// branchKey holds the comparable fields of a branch, i.e. all fields except the labels
+type branchKey struct {
+	Name, SHA, Commit, Details, ID, TargetBranch, Author string
+	Draft, Fork                                          bool
+}
+
+func (currentBranch *Branch) key() branchKey {
+	return branchKey{currentBranch.Name, currentBranch.SHA, currentBranch.Commit, currentBranch.Details, currentBranch.ID, currentBranch.TargetBranch, currentBranch.Author, currentBranch.Draft, currentBranch.Fork}
+}
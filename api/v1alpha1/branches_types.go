@@ -2,6 +2,12 @@ package v1alpha1
 
 type Branches struct {
 	Branches []Branch `json:"branches,omitempty"`
+
+	// More pull requests were open than the upper bound of the poller, which is not part of the status
+	Truncated bool `json:"-"`
+
+	// The pull requests did not change since the listing with the etag of the poll, which is not part of the status
+	NotModified bool `json:"-"`
 }
 
 func (branches *Branches) SetBranches(newBranches []Branch) {
@@ -15,10 +21,6 @@ func (branches *Branches) GetBranches() []Branch {
 func (branches *Branches) Equals(newBranches Branches) bool {
 	found := true
 
-	if branches.GetSize() == 0 {
-		return false
-	}
-
 	if branches.GetSize() != newBranches.GetSize() {
 		return false
 	}
@@ -39,17 +41,54 @@ func (branches *Branches) GetSize() int {
 }
 
 func (branches *Branches) BranchSetDifference(newBranches Branches) (diff []Branch) {
-	m := make(map[Branch]bool)
+	m := make(map[branchKey]bool)
 
 	for _, item := range branches.Branches {
-		m[item] = true
+		m[item.key()] = true
 	}
 
 	for _, item := range newBranches.Branches {
-		if _, ok := m[item]; !ok {
+		if _, ok := m[item.key()]; !ok {
 			diff = append(diff, item)
 		}
 	}
 
 	return
 }
+
+// Diff compares the branches with newBranches by pull request and returns the branches which were added, removed
+// or updated, i.e. point at a new commit.
+func (branches *Branches) Diff(newBranches Branches) (added []Branch, removed []Branch, updated []Branch) {
+	current := make(map[string]Branch)
+	for _, item := range branches.Branches {
+		current[item.Key()] = item
+	}
+
+	found := make(map[string]bool)
+	for _, item := range newBranches.Branches {
+		key := item.Key()
+		currentItem, ok := current[key]
+		if legacyItem, legacy := current[item.Name]; !ok && legacy && len(legacyItem.ID) == 0 {
+			// the branch was stored without the pull request ID by a previous version, it is matched once by name
+			key, currentItem, ok = item.Name, legacyItem, true
+			delete(current, item.Name)
+		}
+		found[key] = true
+		if !ok {
+			added = append(added, item)
+		} else if currentItem.Draft && !item.Draft {
+			// a draft which was marked ready for review is reported as new pull request
+			added = append(added, item)
+		} else if !currentItem.Equals(item) {
+			updated = append(updated, item)
+		}
+	}
+
+	for _, item := range branches.Branches {
+		if !found[item.Key()] {
+			removed = append(removed, item)
+		}
+	}
+
+	return
+}
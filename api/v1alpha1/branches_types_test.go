@@ -0,0 +1,85 @@
+package v1alpha1
+
+import "testing"
+
+func TestBranchesDiff(t *testing.T) {
+	current := Branches{Branches: []Branch{
+		{ID: "1", Name: "feature-a", Commit: "a1"},
+		{ID: "2", Name: "feature-b", Commit: "b1"},
+		{ID: "3", Name: "feature-c", Commit: "c1"},
+	}}
+	next := Branches{Branches: []Branch{
+		{ID: "1", Name: "feature-a", Commit: "a1"},
+		{ID: "2", Name: "feature-b", Commit: "b2"},
+		{ID: "4", Name: "feature-c", Commit: "c1"},
+	}}
+
+	added, removed, updated := current.Diff(next)
+
+	if len(added) != 1 || added[0].ID != "4" {
+		t.Errorf("unexpected added branches %+v", added)
+	}
+	if len(removed) != 1 || removed[0].ID != "3" {
+		t.Errorf("unexpected removed branches %+v", removed)
+	}
+	if len(updated) != 1 || updated[0].ID != "2" || updated[0].Commit != "b2" {
+		t.Errorf("unexpected updated branches %+v", updated)
+	}
+}
+
+func TestBranchesDiffReadyForReview(t *testing.T) {
+	current := Branches{Branches: []Branch{
+		{ID: "1", Name: "feature-a", Commit: "a1", Draft: true},
+		{ID: "2", Name: "feature-b", Commit: "b1", Draft: true},
+	}}
+	next := Branches{Branches: []Branch{
+		{ID: "1", Name: "feature-a", Commit: "a1"},
+		{ID: "2", Name: "feature-b", Commit: "b2", Draft: true},
+	}}
+
+	added, removed, updated := current.Diff(next)
+
+	if len(added) != 1 || added[0].ID != "1" {
+		t.Errorf("expected the pull request ready for review to be added, got %+v", added)
+	}
+	if len(removed) != 0 {
+		t.Errorf("unexpected removed branches %+v", removed)
+	}
+	if len(updated) != 1 || updated[0].ID != "2" {
+		t.Errorf("unexpected updated branches %+v", updated)
+	}
+}
+
+func TestBranchesDiffLegacy(t *testing.T) {
+	// the status of a previous version holds the branches without the pull request ID
+	current := Branches{Branches: []Branch{
+		{Name: "feature-a", Commit: "a1"},
+		{Name: "feature-b", Commit: "b1"},
+		{Name: "feature-c", Commit: "c1"},
+	}}
+	next := Branches{Branches: []Branch{
+		{ID: "1", Name: "feature-a", Commit: "a1"},
+		{ID: "2", Name: "feature-b", Commit: "b2"},
+		{ID: "3", Name: "feature-b", Commit: "b3", Fork: true},
+	}}
+
+	added, removed, updated := current.Diff(next)
+
+	if len(added) != 1 || added[0].ID != "3" {
+		t.Errorf("expected only the second pull request of feature-b to be added, got %+v", added)
+	}
+	if len(removed) != 1 || removed[0].Name != "feature-c" {
+		t.Errorf("unexpected removed branches %+v", removed)
+	}
+	if len(updated) != 1 || updated[0].ID != "2" {
+		t.Errorf("unexpected updated branches %+v", updated)
+	}
+}
+
+func TestBranchesDiffUnchanged(t *testing.T) {
+	var empty Branches
+	added, removed, updated := empty.Diff(Branches{})
+	if len(added) != 0 || len(removed) != 0 || len(updated) != 0 {
+		t.Errorf("expected no differences between empty snapshots")
+	}
+}
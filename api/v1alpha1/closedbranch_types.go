@@ -0,0 +1,26 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	PULLREQUEST_STATE_OPEN     = "open"
+	PULLREQUEST_STATE_MERGED   = "merged"
+	PULLREQUEST_STATE_DECLINED = "declined"
+	PULLREQUEST_STATE_CLOSED   = "closed"
+)
+
+// ClosedBranch is a branch whose pull request is no longer open
+type ClosedBranch struct {
+	Branch `json:",inline"`
+
+	// Final state of the pull request, one of merged, declined or closed
+	State string `json:"state"`
+
+	// The merge commit, if the pull request was merged and the git provider reports it
+	MergeCommit string `json:"mergeCommit,omitempty"`
+
+	// Time at which the operator detected that the pull request is no longer open
+	ClosedTime metav1.Time `json:"closedTime"`
+}
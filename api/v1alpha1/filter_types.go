@@ -0,0 +1,103 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type LabelFilter struct {
+
+	// Only pull requests with all of the labels are included
+	// +kubebuilder:validation:Optional
+	Include []string `json:"include,omitempty"`
+
+	// Pull requests with any of the labels are excluded
+	// +kubebuilder:validation:Optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Matches returns true if the labels contain all included and none of the excluded labels
+func (filter *LabelFilter) Matches(labels []string) bool {
+	found := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		found[label] = true
+	}
+	for _, label := range filter.Include {
+		if !found[label] {
+			return false
+		}
+	}
+	for _, label := range filter.Exclude {
+		if found[label] {
+			return false
+		}
+	}
+	return true
+}
+
+type BranchFilter struct {
+
+	// Only pull requests from source branches matching any of the patterns are included. A pattern is a glob, in
+	// which * matches any characters except /, ** matches any characters and ? matches one character except /, or a
+	// regular expression enclosed in slashes, e.g. /^renovate\/.*$/.
+	// +kubebuilder:validation:Optional
+	Include []string `json:"include,omitempty"`
+
+	// Pull requests from source branches matching any of the patterns are excluded
+	// +kubebuilder:validation:Optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Validate returns an error, if any of the patterns is not a valid regular expression
+func (filter *BranchFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if _, err := compileBranchPattern(pattern); err != nil {
+			return fmt.Errorf("invalid source branch pattern %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the name matches any included pattern, or no patterns are included, and none of the
+// excluded patterns. Invalid patterns do not match.
+func (filter *BranchFilter) Matches(name string) bool {
+	if len(filter.Include) > 0 && !matchesAnyBranchPattern(filter.Include, name) {
+		return false
+	}
+	return !matchesAnyBranchPattern(filter.Exclude, name)
+}
+
+func matchesAnyBranchPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if expression, err := compileBranchPattern(pattern); err == nil && expression.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileBranchPattern compiles a regular expression enclosed in slashes as is, and translates a glob into an
+// anchored regular expression
+func compileBranchPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expression.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expression.WriteString("$")
+	return regexp.Compile(expression.String())
+}
@@ -0,0 +1,52 @@
+package v1alpha1
+
+import "testing"
+
+func TestLabelFilterMatches(t *testing.T) {
+	filter := LabelFilter{Include: []string{"ci/run"}, Exclude: []string{"wip"}}
+	for _, test := range []struct {
+		labels  []string
+		matches bool
+	}{
+		{[]string{"ci/run"}, true},
+		{[]string{"ci/run", "dependencies"}, true},
+		{[]string{"ci/run", "wip"}, false},
+		{[]string{"dependencies"}, false},
+		{nil, false},
+	} {
+		if matches := filter.Matches(test.labels); matches != test.matches {
+			t.Errorf("expected %t for the labels %v, got %t", test.matches, test.labels, matches)
+		}
+	}
+
+	if !(&LabelFilter{}).Matches(nil) {
+		t.Error("expected an empty filter to match all pull requests")
+	}
+}
+
+func TestBranchFilterMatches(t *testing.T) {
+	filter := BranchFilter{Include: []string{"renovate/*", "dependabot/**", "/^hotfix-[0-9]+$/"}, Exclude: []string{"renovate/major-*"}}
+	for _, test := range []struct {
+		name    string
+		matches bool
+	}{
+		{"renovate/golang-1.x", true},
+		{"renovate/major-kubernetes", false},
+		{"renovate/group/golang", false},
+		{"dependabot/go_modules/golang.org/x/net-0.7.0", true},
+		{"hotfix-42", true},
+		{"hotfix-x", false},
+		{"feature/renovate/golang", false},
+	} {
+		if matches := filter.Matches(test.name); matches != test.matches {
+			t.Errorf("expected %t for the source branch %s, got %t", test.matches, test.name, matches)
+		}
+	}
+
+	if !(&BranchFilter{Exclude: []string{"renovate/**"}}).Matches("feature-a") {
+		t.Error("expected a filter without included patterns to match all other source branches")
+	}
+	if err := (&BranchFilter{Include: []string{"/renovate/(/"}}).Validate(); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
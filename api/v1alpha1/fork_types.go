@@ -0,0 +1,96 @@
+package v1alpha1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	FORK_POLICY_INCLUDE = "Include"
+	FORK_POLICY_EXCLUDE = "Exclude"
+	FORK_POLICY_TRUSTED = "Trusted"
+
+	// Reasons for which a pull request is held back
+	HELD_REASON_FORK_EXCLUDED       = "ForkExcluded"
+	HELD_REASON_UNTRUSTED_AUTHOR    = "UntrustedAuthor"
+	HELD_REASON_AWAITING_OK_TO_TEST = "AwaitingOkToTest"
+
+	// Comment of a trusted user, which approves a pull request from a fork of an untrusted author for testing
+	OK_TO_TEST_COMMENT = "/ok-to-test"
+)
+
+type ForkPolicy struct {
+
+	// Include stores pull requests from forks like any other pull request, Exclude holds them back and Trusted stores
+	// only the pull requests from forks of trusted authors
+	// +kubebuilder:validation:Enum=Include;Exclude;Trusted
+	// +kubebuilder:default=Include
+	// +kubebuilder:validation:Optional
+	Policy string `json:"policy,omitempty"`
+
+	// Authors are trusted by their user name, i.e. the login of Github or the user slug of Bitbucket Server
+	// +kubebuilder:validation:Optional
+	Authors []string `json:"authors,omitempty"`
+
+	// Members of the Github organizations are trusted
+	// +kubebuilder:validation:Optional
+	Organizations []string `json:"organizations,omitempty"`
+
+	// Members of the Github teams are trusted, each team is given as organization/team-slug
+	// +kubebuilder:validation:Optional
+	Teams []string `json:"teams,omitempty"`
+
+	// OkToTest releases the pull requests from forks of untrusted authors, once a trusted user commented /ok-to-test.
+	// Each new commit has to be approved by another comment. Requires the Trusted policy.
+	// +kubebuilder:default=false
+	// +kubebuilder:validation:Optional
+	OkToTest bool `json:"okToTest,omitempty"`
+}
+
+// TrustsAuthor returns true if the author is in the allow-list, the memberships are not looked up
+func (policy *ForkPolicy) TrustsAuthor(author string) bool {
+	for _, trusted := range policy.Authors {
+		if strings.EqualFold(trusted, author) {
+			return true
+		}
+	}
+	return false
+}
+
+// HeldBranch is a branch whose pull request is open, but is not stored in the source branches
+type HeldBranch struct {
+	Branch `json:",inline"`
+
+	// The reason for which the pull request is held back, one of ForkExcluded, UntrustedAuthor or AwaitingOkToTest
+	Reason string `json:"reason"`
+
+	// Time at which the operator first saw the head commit. If the git provider does not report the pushes, only
+	// later comments approve the commit for testing.
+	SeenTime metav1.Time `json:"seenTime"`
+}
+
+// TestApproval records the approval of a pull request from a fork of an untrusted author for testing
+type TestApproval struct {
+	// Identifier of the pull request at the git provider
+	ID string `json:"id"`
+
+	// The head commit, which was approved for testing
+	Commit string `json:"commit"`
+
+	// User name of the trusted user, who commented /ok-to-test
+	ApprovedBy string `json:"approvedBy"`
+
+	// Time of the approving comment, only later comments approve new commits
+	ApprovedTime metav1.Time `json:"approvedTime"`
+}
+
+// IsOkToTest returns true if a line of the comment is the /ok-to-test command
+func IsOkToTest(comment string) bool {
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.TrimSpace(line) == OK_TO_TEST_COMMENT {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+package v1alpha1
+
+type Gerrit struct {
+
+	// +kubebuilder:validation:Required
+	Url string `json:"url"`
+
+	// +kubebuilder:validation:Required
+	Project string `json:"project"`
+}
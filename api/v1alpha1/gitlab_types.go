@@ -0,0 +1,11 @@
+package v1alpha1
+
+type Gitlab struct {
+
+	// +kubebuilder:validation:Required
+	Url string `json:"url"`
+
+	// Project path (e.g. group/subgroup/project) or numeric project ID
+	// +kubebuilder:validation:Required
+	Project string `json:"project"`
+}
@@ -1,14 +1,23 @@
 package v1alpha1
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 const (
-	BITBUCKET_PROVIDER_NAME = "Bitbucket"
-	GITHUB_PROVIDER_NAME    = "Github"
+	AZUREDEVOPS_PROVIDER_NAME    = "AzureDevOps"
+	BITBUCKET_PROVIDER_NAME      = "Bitbucket"
+	BITBUCKETCLOUD_PROVIDER_NAME = "BitbucketCloud"
+	GERRIT_PROVIDER_NAME         = "Gerrit"
+	GITHUB_PROVIDER_NAME         = "Github"
+	GITLAB_PROVIDER_NAME         = "Gitlab"
+	GITEA_PROVIDER_NAME          = "Gitea"
 )
 
 type GitProvider struct {
 
 	// Git Provider type
-	// +kubebuilder:validation:Enum=Bitbucket;Github
+	// +kubebuilder:validation:Enum=AzureDevOps;Bitbucket;BitbucketCloud;Gerrit;Github;Gitlab;Gitea
 	// +kubebuilder:validation:Required
 	Provider string `json:"provider"`
 
@@ -16,13 +25,44 @@ type GitProvider struct {
 	// +kubebuilder:validation:Required
 	InsecureSkipVerify bool `json:"insecureSkipVerify"`
 
+	// CA certificates, which are trusted in addition to the system roots to verify the git provider's certificate
+	// +kubebuilder:validation:Optional
+	CABundle *CABundle `json:"caBundle,omitempty"`
+
+	// Secret of type kubernetes.io/tls with the keys tls.crt and tls.key, which is presented as client certificate for mutual TLS
+	// +kubebuilder:validation:Optional
+	ClientCertSecretRef string `json:"clientCertSecretRef,omitempty"`
+
+	// URL of the HTTP(S) proxy to connect to the git provider, the proxy of the operator's environment is used if not set
+	// +kubebuilder:validation:Optional
+	Proxy string `json:"proxy,omitempty"`
+
+	// Timeout of a poll of the git provider, including all pages of pull requests, defaults to 30s
+	// +kubebuilder:validation:Optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
 	// Git Provider credentials
 	// +kubebuilder:validation:Optional
 	SecretRef string `json:"secretRef"`
 
+	// +kubebuilder:validation:Optional
+	AzureDevOps AzureDevOps `json:"azureDevOps"`
+
 	// +kubebuilder:validation:Optional
 	Bitbucket Bitbucket `json:"bitbucket"`
 
+	// +kubebuilder:validation:Optional
+	BitbucketCloud BitbucketCloud `json:"bitbucketCloud"`
+
+	// +kubebuilder:validation:Optional
+	Gerrit Gerrit `json:"gerrit"`
+
 	// +kubebuilder:validation:Optional
 	Github Github `json:"github"`
+
+	// +kubebuilder:validation:Optional
+	Gitlab Gitlab `json:"gitlab"`
+
+	// +kubebuilder:validation:Optional
+	Gitea Gitea `json:"gitea"`
 }
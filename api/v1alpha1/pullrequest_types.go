@@ -31,11 +31,44 @@ type PullRequestSpec struct {
 
 	// TargetBranch points at the object specifying the target branch
 	// +kubebuilder:validation:Required
-	TargetBranch Branch `json:"targetBranch"`
+	TargetBranch TargetBranch `json:"targetBranch"`
 
 	// Interval at which to reconcile the git provider.
 	// +required
 	Interval metav1.Duration `json:"interval"`
+
+	// MaxPullRequests is the upper bound for the number of pull requests stored in the status. The details of a
+	// pull request from Github take up to about 25 KB, the default keeps the status below the size limit of etcd.
+	// +kubebuilder:default=50
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	MaxPullRequests int `json:"maxPullRequests,omitempty"`
+
+	// ClosedRetention is the duration for which closed, merged and declined pull requests are kept in the status.
+	// A duration of 0 disables the recording of closed pull requests.
+	// +kubebuilder:default="24h"
+	// +kubebuilder:validation:Optional
+	ClosedRetention *metav1.Duration `json:"closedRetention,omitempty"`
+
+	// IncludeDrafts includes draft pull requests, which are skipped by default. Gitea pull requests are never drafts.
+	// +kubebuilder:default=false
+	// +kubebuilder:validation:Optional
+	IncludeDrafts bool `json:"includeDrafts,omitempty"`
+
+	// Labels filters the pull requests by their labels, only matching pull requests are stored in the status.
+	// Bitbucket Server and Bitbucket Cloud do not support labels.
+	// +kubebuilder:validation:Optional
+	Labels *LabelFilter `json:"labels,omitempty"`
+
+	// SourceBranches filters the pull requests by the names of their source branches, e.g. to route the pull requests
+	// of renovate and dependabot to another pipeline
+	// +kubebuilder:validation:Optional
+	SourceBranches *BranchFilter `json:"sourceBranches,omitempty"`
+
+	// Forks restricts the pull requests opened from forks of the repository, which are included by default. Forks are
+	// detected for Github and Bitbucket Server only.
+	// +kubebuilder:validation:Optional
+	Forks *ForkPolicy `json:"forks,omitempty"`
 }
 
 // PullRequestStatus defines the observed state of PullRequest
@@ -43,8 +76,39 @@ type PullRequestStatus struct {
 	// The branches from which a pull requst was opened to the target branch
 	SourceBranches Branches `json:"sourceBranches,omitempty"`
 
+	// The pull requests which were opened since the previous snapshot of the source branches
+	AddedBranches []Branch `json:"addedBranches,omitempty"`
+
+	// The pull requests which were closed since the previous snapshot of the source branches
+	RemovedBranches []Branch `json:"removedBranches,omitempty"`
+
+	// The pull requests which point at a new head commit since the previous snapshot of the source branches
+	UpdatedBranches []Branch `json:"updatedBranches,omitempty"`
+
+	// The pull requests which were closed, merged or declined within the retention window
+	ClosedBranches []ClosedBranch `json:"closedBranches,omitempty"`
+
+	// The open pull requests which are held back by the fork policy together with the reason
+	HeldBranches []HeldBranch `json:"heldBranches,omitempty"`
+
+	// The pull requests from forks of untrusted authors, which were approved for testing by a trusted user. The last
+	// approval is kept, while a new commit awaits the approval.
+	Approvals []TestApproval `json:"approvals,omitempty"`
+
 	ETag string `json:"etag,omitempty"`
 
+	// The generation of the spec, which was reconciled last
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// More pull requests to the target branch were open than maxPullRequests, the further pull requests are ignored
+	Truncated bool `json:"truncated,omitempty"`
+
+	// The request quota reported by the git provider with the last poll
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+
+	// The number of consecutive failed reconciliations, reset by a successful poll
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	// +listType=map
@@ -56,6 +120,9 @@ type PullRequestStatus struct {
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // PullRequest is the Schema for the pullrequests API
 type PullRequest struct {
@@ -0,0 +1,19 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type RateLimit struct {
+
+	// Number of requests per rate limit window, 0 if the git provider does not report it
+	// +kubebuilder:validation:Optional
+	Limit int `json:"limit,omitempty"`
+
+	// Number of requests left in the current rate limit window
+	Remaining int `json:"remaining"`
+
+	// Time at which the rate limit window resets
+	// +kubebuilder:validation:Optional
+	Reset *metav1.Time `json:"reset,omitempty"`
+}
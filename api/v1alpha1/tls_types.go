@@ -0,0 +1,17 @@
+package v1alpha1
+
+type CABundle struct {
+
+	// Name of the secret holding the CA bundle
+	// +kubebuilder:validation:Optional
+	SecretRef string `json:"secretRef,omitempty"`
+
+	// Name of the configmap holding the CA bundle
+	// +kubebuilder:validation:Optional
+	ConfigMapRef string `json:"configMapRef,omitempty"`
+
+	// Key of the PEM encoded CA certificates in the secret or configmap
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=ca.crt
+	Key string `json:"key,omitempty"`
+}
@@ -26,6 +26,21 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureDevOps) DeepCopyInto(out *AzureDevOps) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureDevOps.
+func (in *AzureDevOps) DeepCopy() *AzureDevOps {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureDevOps)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Bitbucket) DeepCopyInto(out *Bitbucket) {
 	*out = *in
@@ -41,9 +56,29 @@ func (in *Bitbucket) DeepCopy() *Bitbucket {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BitbucketCloud) DeepCopyInto(out *BitbucketCloud) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BitbucketCloud.
+func (in *BitbucketCloud) DeepCopy() *BitbucketCloud {
+	if in == nil {
+		return nil
+	}
+	out := new(BitbucketCloud)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Branch) DeepCopyInto(out *Branch) {
 	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Branch.
@@ -56,13 +91,40 @@ func (in *Branch) DeepCopy() *Branch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BranchFilter) DeepCopyInto(out *BranchFilter) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BranchFilter.
+func (in *BranchFilter) DeepCopy() *BranchFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(BranchFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Branches) DeepCopyInto(out *Branches) {
 	*out = *in
 	if in.Branches != nil {
 		in, out := &in.Branches, &out.Branches
 		*out = make([]Branch, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -76,11 +138,103 @@ func (in *Branches) DeepCopy() *Branches {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundle) DeepCopyInto(out *CABundle) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundle.
+func (in *CABundle) DeepCopy() *CABundle {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClosedBranch) DeepCopyInto(out *ClosedBranch) {
+	*out = *in
+	in.Branch.DeepCopyInto(&out.Branch)
+	in.ClosedTime.DeepCopyInto(&out.ClosedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClosedBranch.
+func (in *ClosedBranch) DeepCopy() *ClosedBranch {
+	if in == nil {
+		return nil
+	}
+	out := new(ClosedBranch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForkPolicy) DeepCopyInto(out *ForkPolicy) {
+	*out = *in
+	if in.Authors != nil {
+		in, out := &in.Authors, &out.Authors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Organizations != nil {
+		in, out := &in.Organizations, &out.Organizations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Teams != nil {
+		in, out := &in.Teams, &out.Teams
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForkPolicy.
+func (in *ForkPolicy) DeepCopy() *ForkPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ForkPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Gerrit) DeepCopyInto(out *Gerrit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Gerrit.
+func (in *Gerrit) DeepCopy() *Gerrit {
+	if in == nil {
+		return nil
+	}
+	out := new(Gerrit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitProvider) DeepCopyInto(out *GitProvider) {
 	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(CABundle)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	out.AzureDevOps = in.AzureDevOps
 	out.Bitbucket = in.Bitbucket
+	out.BitbucketCloud = in.BitbucketCloud
+	out.Gerrit = in.Gerrit
 	out.Github = in.Github
+	out.Gitlab = in.Gitlab
+	out.Gitea = in.Gitea
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitProvider.
@@ -93,6 +247,21 @@ func (in *GitProvider) DeepCopy() *GitProvider {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Gitea) DeepCopyInto(out *Gitea) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Gitea.
+func (in *Gitea) DeepCopy() *Gitea {
+	if in == nil {
+		return nil
+	}
+	out := new(Gitea)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Github) DeepCopyInto(out *Github) {
 	*out = *in
@@ -108,12 +277,69 @@ func (in *Github) DeepCopy() *Github {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Gitlab) DeepCopyInto(out *Gitlab) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Gitlab.
+func (in *Gitlab) DeepCopy() *Gitlab {
+	if in == nil {
+		return nil
+	}
+	out := new(Gitlab)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeldBranch) DeepCopyInto(out *HeldBranch) {
+	*out = *in
+	in.Branch.DeepCopyInto(&out.Branch)
+	in.SeenTime.DeepCopyInto(&out.SeenTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeldBranch.
+func (in *HeldBranch) DeepCopy() *HeldBranch {
+	if in == nil {
+		return nil
+	}
+	out := new(HeldBranch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LabelFilter) DeepCopyInto(out *LabelFilter) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LabelFilter.
+func (in *LabelFilter) DeepCopy() *LabelFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(LabelFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullRequest) DeepCopyInto(out *PullRequest) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -170,9 +396,29 @@ func (in *PullRequestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullRequestSpec) DeepCopyInto(out *PullRequestSpec) {
 	*out = *in
-	out.GitProvider = in.GitProvider
+	in.GitProvider.DeepCopyInto(&out.GitProvider)
 	out.TargetBranch = in.TargetBranch
 	out.Interval = in.Interval
+	if in.ClosedRetention != nil {
+		in, out := &in.ClosedRetention, &out.ClosedRetention
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = new(LabelFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SourceBranches != nil {
+		in, out := &in.SourceBranches, &out.SourceBranches
+		*out = new(BranchFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Forks != nil {
+		in, out := &in.Forks, &out.Forks
+		*out = new(ForkPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullRequestSpec.
@@ -189,6 +435,53 @@ func (in *PullRequestSpec) DeepCopy() *PullRequestSpec {
 func (in *PullRequestStatus) DeepCopyInto(out *PullRequestStatus) {
 	*out = *in
 	in.SourceBranches.DeepCopyInto(&out.SourceBranches)
+	if in.AddedBranches != nil {
+		in, out := &in.AddedBranches, &out.AddedBranches
+		*out = make([]Branch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemovedBranches != nil {
+		in, out := &in.RemovedBranches, &out.RemovedBranches
+		*out = make([]Branch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UpdatedBranches != nil {
+		in, out := &in.UpdatedBranches, &out.UpdatedBranches
+		*out = make([]Branch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClosedBranches != nil {
+		in, out := &in.ClosedBranches, &out.ClosedBranches
+		*out = make([]ClosedBranch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HeldBranches != nil {
+		in, out := &in.HeldBranches, &out.HeldBranches
+		*out = make([]HeldBranch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Approvals != nil {
+		in, out := &in.Approvals, &out.Approvals
+		*out = make([]TestApproval, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RateLimit)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -207,3 +500,53 @@ func (in *PullRequestStatus) DeepCopy() *PullRequestStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimit) DeepCopyInto(out *RateLimit) {
+	*out = *in
+	if in.Reset != nil {
+		in, out := &in.Reset, &out.Reset
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimit.
+func (in *RateLimit) DeepCopy() *RateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetBranch) DeepCopyInto(out *TargetBranch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetBranch.
+func (in *TargetBranch) DeepCopy() *TargetBranch {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetBranch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestApproval) DeepCopyInto(out *TestApproval) {
+	*out = *in
+	in.ApprovedTime.DeepCopyInto(&out.ApprovedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestApproval.
+func (in *TestApproval) DeepCopy() *TestApproval {
+	if in == nil {
+		return nil
+	}
+	out := new(TestApproval)
+	in.DeepCopyInto(out)
+	return out
+}
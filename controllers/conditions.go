@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+const (
+	// Condition types with kstatus semantics, as expected by kubectl wait, Flux and Argo CD health checks
+	ConditionReady       = "Ready"
+	ConditionReconciling = "Reconciling"
+	// The reconciliation failed permanently and waits for a change of the spec or the secret
+	ConditionStalled = "Stalled"
+
+	// Reason of the reconciling condition, while a failed poll is retried
+	ReconcilingRetryReason = "ProgressingWithRetry"
+)
+
+// markReady records a successful poll of the current generation
+func markReady(pullrequest *pipelinev1alpha1.PullRequest) {
+	meta.SetStatusCondition(&pullrequest.Status.Conditions, metav1.Condition{
+		Type:               ConditionReady,
+		ObservedGeneration: pullrequest.GetGeneration(),
+		Reason:             ReconcileSuccessReason,
+		Status:             metav1.ConditionTrue,
+		Message:            "Success",
+	})
+	meta.RemoveStatusCondition(&pullrequest.Status.Conditions, ConditionReconciling)
+	meta.RemoveStatusCondition(&pullrequest.Status.Conditions, ConditionStalled)
+	pullrequest.Status.ObservedGeneration = pullrequest.GetGeneration()
+
+	// deprecated condition types, kept for one release
+	pullrequest.AddOrReplaceCondition(metav1.Condition{
+		Type:               ReconcileSuccess,
+		LastTransitionTime: metav1.Now(),
+		ObservedGeneration: pullrequest.GetGeneration(),
+		Reason:             ReconcileSuccessReason,
+		Status:             metav1.ConditionTrue,
+		Message:            "Success",
+	})
+	meta.RemoveStatusCondition(&pullrequest.Status.Conditions, ReconcileError)
+}
+
+// markFailed records a failed poll of the current generation. A stalled PullRequest is not retried, otherwise it stays
+// reconciling until the retry succeeds.
+func markFailed(pullrequest *pipelinev1alpha1.PullRequest, reason string, message string, stalled bool) {
+	meta.SetStatusCondition(&pullrequest.Status.Conditions, metav1.Condition{
+		Type:               ConditionReady,
+		ObservedGeneration: pullrequest.GetGeneration(),
+		Reason:             reason,
+		Status:             metav1.ConditionFalse,
+		Message:            message,
+	})
+	if stalled {
+		meta.SetStatusCondition(&pullrequest.Status.Conditions, metav1.Condition{
+			Type:               ConditionStalled,
+			ObservedGeneration: pullrequest.GetGeneration(),
+			Reason:             reason,
+			Status:             metav1.ConditionTrue,
+			Message:            message,
+		})
+		meta.RemoveStatusCondition(&pullrequest.Status.Conditions, ConditionReconciling)
+	} else {
+		meta.SetStatusCondition(&pullrequest.Status.Conditions, metav1.Condition{
+			Type:               ConditionReconciling,
+			ObservedGeneration: pullrequest.GetGeneration(),
+			Reason:             ReconcilingRetryReason,
+			Status:             metav1.ConditionTrue,
+			Message:            message,
+		})
+		meta.RemoveStatusCondition(&pullrequest.Status.Conditions, ConditionStalled)
+	}
+	pullrequest.Status.ObservedGeneration = pullrequest.GetGeneration()
+
+	// deprecated condition types, kept for one release
+	pullrequest.AddOrReplaceCondition(metav1.Condition{
+		Type:               ReconcileError,
+		LastTransitionTime: metav1.Now(),
+		ObservedGeneration: pullrequest.GetGeneration(),
+		Reason:             reason,
+		Status:             metav1.ConditionFalse,
+		Message:            message,
+	})
+	meta.RemoveStatusCondition(&pullrequest.Status.Conditions, ReconcileSuccess)
+}
+
+// isReady returns true if the current generation was polled successfully
+func isReady(pullrequest *pipelinev1alpha1.PullRequest) bool {
+	return pullrequest.Status.ObservedGeneration == pullrequest.GetGeneration() && meta.IsStatusConditionTrue(pullrequest.Status.Conditions, ConditionReady)
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+func TestConditionTransitions(t *testing.T) {
+	pullrequest := &pipelinev1alpha1.PullRequest{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	if isReady(pullrequest) {
+		t.Error("expected a new PullRequest not to be ready")
+	}
+
+	markFailed(pullrequest, ReconcileErrorReason, "connection refused", false)
+	if !meta.IsStatusConditionTrue(pullrequest.Status.Conditions, ConditionReconciling) || !meta.IsStatusConditionFalse(pullrequest.Status.Conditions, ConditionReady) {
+		t.Errorf("expected a transient error to keep the PullRequest reconciling, got %+v", pullrequest.Status.Conditions)
+	}
+
+	markFailed(pullrequest, StalledAuthenticationReason, "unauthorized", true)
+	if !meta.IsStatusConditionTrue(pullrequest.Status.Conditions, ConditionStalled) || meta.FindStatusCondition(pullrequest.Status.Conditions, ConditionReconciling) != nil {
+		t.Errorf("expected a permanent error to stall the PullRequest, got %+v", pullrequest.Status.Conditions)
+	}
+	if pullrequest.Status.ObservedGeneration != 2 {
+		t.Errorf("expected the observed generation 2, got %d", pullrequest.Status.ObservedGeneration)
+	}
+
+	markReady(pullrequest)
+	if !isReady(pullrequest) {
+		t.Errorf("expected the PullRequest to be ready, got %+v", pullrequest.Status.Conditions)
+	}
+	for _, conditionType := range []string{ConditionStalled, ConditionReconciling, ReconcileError} {
+		if meta.FindStatusCondition(pullrequest.Status.Conditions, conditionType) != nil {
+			t.Errorf("expected the %s condition to be removed after a successful poll", conditionType)
+		}
+	}
+	if !meta.IsStatusConditionTrue(pullrequest.Status.Conditions, ReconcileSuccess) {
+		t.Error("expected the deprecated Success condition to be kept")
+	}
+
+	// a new generation has to be reconciled again
+	pullrequest.Generation = 3
+	if isReady(pullrequest) {
+		t.Error("expected a changed spec not to be ready")
+	}
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	gitApi "github.com/jquad-group/pullrequest-operator/pkg/git"
+)
+
+const (
+	// Reasons of the stalled condition
+	StalledAuthenticationReason = "AuthenticationFailed"
+	StalledNotFoundReason       = "NotFound"
+	StalledConfigurationReason  = "InvalidConfiguration"
+
+	// Delay of the first retry after a transient error, doubled with each consecutive failure
+	BACKOFF_BASE_DELAY = 5 * time.Second
+	// Upper bound of the delay between retries after transient errors
+	BACKOFF_MAX_DELAY = 5 * time.Minute
+)
+
+// configurationError marks an error of the PullRequest spec or of the referenced secret, which is not resolved by
+// retrying
+type configurationError struct {
+	err error
+}
+
+func (e *configurationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configurationError) Unwrap() error {
+	return e.err
+}
+
+func misconfigured(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &configurationError{err: err}
+}
+
+// classifyError returns the reason of the stalled condition, if the error is permanent and waits for a change of the
+// spec or the secret. Network errors and server errors are transient.
+func classifyError(err error) (string, bool) {
+	var configErr *configurationError
+	if errors.As(err, &configErr) {
+		return StalledConfigurationReason, true
+	}
+	// the referenced secret or configmap does not exist
+	if apierrors.IsNotFound(err) {
+		return StalledNotFoundReason, true
+	}
+	if statusCode, ok := gitApi.StatusCode(err); ok {
+		switch statusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return StalledAuthenticationReason, true
+		case http.StatusNotFound, http.StatusGone:
+			return StalledNotFoundReason, true
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return StalledConfigurationReason, true
+		}
+	}
+	return "", false
+}
+
+// backoff returns the delay before the next retry after the given number of consecutive transient failures
+func backoff(failures int) time.Duration {
+	delay := BACKOFF_BASE_DELAY
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= BACKOFF_MAX_DELAY {
+			return BACKOFF_MAX_DELAY
+		}
+	}
+	return delay
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	githubClient "github.com/google/go-github/v42/github"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	gitApi "github.com/jquad-group/pullrequest-operator/pkg/git"
+)
+
+func TestClassifyError(t *testing.T) {
+	githubRequest := &http.Request{Method: http.MethodGet, URL: &url.URL{Scheme: "https", Host: "api.github.com"}}
+	for name, test := range map[string]struct {
+		err     error
+		reason  string
+		stalled bool
+	}{
+		"invalid secret":     {misconfigured(errors.New("invalid HTTP auth option: 'accessToken' must be set")), StalledConfigurationReason, true},
+		"secret not found":   {apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "github-secret"), StalledNotFoundReason, true},
+		"unauthorized":       {&gitApi.StatusError{Method: http.MethodGet, StatusCode: http.StatusUnauthorized}, StalledAuthenticationReason, true},
+		"github not found":   {&githubClient.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound, Request: githubRequest}}, StalledNotFoundReason, true},
+		"server error":       {&gitApi.StatusError{Method: http.MethodGet, StatusCode: http.StatusBadGateway}, "", false},
+		"connection refused": {errors.New("dial tcp 127.0.0.1:443: connect: connection refused"), "", false},
+	} {
+		reason, stalled := classifyError(test.err)
+		if reason != test.reason || stalled != test.stalled {
+			t.Errorf("%s: expected %q/%t, got %q/%t", name, test.reason, test.stalled, reason, stalled)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for failures, expected := range map[int]time.Duration{
+		1:   5 * time.Second,
+		2:   10 * time.Second,
+		4:   40 * time.Second,
+		7:   BACKOFF_MAX_DELAY,
+		100: BACKOFF_MAX_DELAY,
+	} {
+		if delay := backoff(failures); delay != expected {
+			t.Errorf("%d failures: expected %s, got %s", failures, expected, delay)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+	gitApi "github.com/jquad-group/pullrequest-operator/pkg/git"
+)
+
+// applyForkPolicy splits the pull requests into the ones stored in the source branches and the ones held back by the
+// fork policy. Pull requests which are not opened from a fork are never held back. The approvals for testing of the
+// previous reconciliation are kept as long as the pull request is open, so that a new commit is approved by a later
+// comment only. The held back pull requests of the previous reconciliation keep the time their head commit was seen.
+func applyForkPolicy(ctx context.Context, policy *pipelinev1alpha1.ForkPolicy, checker gitApi.MembershipChecker, lister gitApi.CommentLister, branches pipelinev1alpha1.Branches, previousHeld []pipelinev1alpha1.HeldBranch, approvals []pipelinev1alpha1.TestApproval) (pipelinev1alpha1.Branches, []pipelinev1alpha1.HeldBranch, []pipelinev1alpha1.TestApproval, error) {
+	if policy == nil || len(policy.Policy) == 0 || policy.Policy == pipelinev1alpha1.FORK_POLICY_INCLUDE {
+		return branches, nil, nil, nil
+	}
+
+	var included pipelinev1alpha1.Branches
+	var held []pipelinev1alpha1.HeldBranch
+	var approved []pipelinev1alpha1.TestApproval
+	// authors usually open several pull requests, the memberships are looked up once per author
+	trustedUsers := map[string]bool{}
+	isTrusted := func(user string) (bool, error) {
+		trusted, ok := trustedUsers[user]
+		if !ok {
+			var err error
+			trusted, err = isTrustedAuthor(ctx, policy, checker, user)
+			if err != nil {
+				return false, err
+			}
+			trustedUsers[user] = trusted
+		}
+		return trusted, nil
+	}
+	now := metav1.Now()
+	seenTime := func(branch pipelinev1alpha1.Branch) metav1.Time {
+		for i := range previousHeld {
+			if previousHeld[i].Key() == branch.Key() && previousHeld[i].Commit == branch.Commit && !previousHeld[i].SeenTime.IsZero() {
+				return previousHeld[i].SeenTime
+			}
+		}
+		return now
+	}
+	for _, branch := range branches.Branches {
+		if !branch.Fork {
+			included.Branches = append(included.Branches, branch)
+			continue
+		}
+		if policy.Policy == pipelinev1alpha1.FORK_POLICY_EXCLUDE {
+			held = append(held, pipelinev1alpha1.HeldBranch{Branch: branch.WithoutDetails(), Reason: pipelinev1alpha1.HELD_REASON_FORK_EXCLUDED, SeenTime: seenTime(branch)})
+			continue
+		}
+		trusted, err := isTrusted(branch.Author)
+		if err != nil {
+			return included, held, approved, err
+		}
+		if trusted {
+			included.Branches = append(included.Branches, branch)
+			continue
+		}
+		if !policy.OkToTest {
+			held = append(held, pipelinev1alpha1.HeldBranch{Branch: branch.WithoutDetails(), Reason: pipelinev1alpha1.HELD_REASON_UNTRUSTED_AUTHOR, SeenTime: seenTime(branch)})
+			continue
+		}
+		previous := findApproval(approvals, branch.Key())
+		seen := seenTime(branch)
+		approval, err := approveForTesting(ctx, lister, isTrusted, branch, previous, seen.Time)
+		if err != nil {
+			return included, held, approved, err
+		}
+		if approval != nil {
+			included.Branches = append(included.Branches, branch)
+			approved = append(approved, *approval)
+			continue
+		}
+		held = append(held, pipelinev1alpha1.HeldBranch{Branch: branch.WithoutDetails(), Reason: pipelinev1alpha1.HELD_REASON_AWAITING_OK_TO_TEST, SeenTime: seen})
+		if previous != nil {
+			approved = append(approved, *previous)
+		}
+	}
+	return included, held, approved, nil
+}
+
+// approveForTesting returns the approval of the head commit of the pull request, if a trusted user commented
+// /ok-to-test after the head commit was pushed, or after the head commit was seen if the git provider does not report
+// the pushes. If a previous commit was approved, only a later comment approves the head commit.
+func approveForTesting(ctx context.Context, lister gitApi.CommentLister, isTrusted func(string) (bool, error), branch pipelinev1alpha1.Branch, previous *pipelinev1alpha1.TestApproval, seen time.Time) (*pipelinev1alpha1.TestApproval, error) {
+	if previous != nil && previous.Commit == branch.Commit {
+		return previous, nil
+	}
+	if lister == nil {
+		return nil, errors.New("the git provider does not support comments")
+	}
+	comments, err := lister.ListComments(ctx, branch.ID)
+	if err != nil {
+		return nil, err
+	}
+	// the comments approved previous commits, e.g. if the operator did not see the approval before the push
+	since, err := lister.GetCommitTime(ctx, branch.ID, branch.Commit)
+	if err != nil {
+		return nil, err
+	}
+	// the commit date is set by the author, e.g. to a time before an earlier approval
+	if since.IsZero() {
+		since = seen
+	}
+	if previous != nil && previous.ApprovedTime.After(since) {
+		since = previous.ApprovedTime.Time
+	}
+	// the latest approval is searched, the git providers list the comments in different orders
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Created.After(comments[j].Created)
+	})
+	for _, comment := range comments {
+		if !comment.Created.After(since) {
+			break
+		}
+		if !pipelinev1alpha1.IsOkToTest(comment.Body) || comment.Author == branch.Author {
+			continue
+		}
+		trusted, err := isTrusted(comment.Author)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			return &pipelinev1alpha1.TestApproval{
+				ID:           branch.Key(),
+				Commit:       branch.Commit,
+				ApprovedBy:   comment.Author,
+				ApprovedTime: metav1.NewTime(comment.Created),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+func findApproval(approvals []pipelinev1alpha1.TestApproval, id string) *pipelinev1alpha1.TestApproval {
+	for i := range approvals {
+		if approvals[i].ID == id {
+			return &approvals[i]
+		}
+	}
+	return nil
+}
+
+// isTrustedAuthor returns true if the author is in the allow-list or a member of any of the organizations or teams
+func isTrustedAuthor(ctx context.Context, policy *pipelinev1alpha1.ForkPolicy, checker gitApi.MembershipChecker, author string) (bool, error) {
+	// the author is unknown, e.g. if the account was deleted
+	if len(author) == 0 {
+		return false, nil
+	}
+	if policy.TrustsAuthor(author) {
+		return true, nil
+	}
+	if (len(policy.Organizations) > 0 || len(policy.Teams) > 0) && checker == nil {
+		return false, errors.New("the git provider does not support memberships")
+	}
+	for _, organization := range policy.Organizations {
+		member, err := checker.IsOrganizationMember(ctx, organization, author)
+		if err != nil || member {
+			return member, err
+		}
+	}
+	for _, team := range policy.Teams {
+		organization, slug, _ := strings.Cut(team, "/")
+		member, err := checker.IsTeamMember(ctx, organization, slug, author)
+		if err != nil || member {
+			return member, err
+		}
+	}
+	return false, nil
+}
+
+// heldBranchesChanged returns true if other pull requests, other commits or other reasons are held back, or if the
+// time a commit was seen changed, e.g. for the held back pull requests stored by a previous version
+func heldBranchesChanged(current []pipelinev1alpha1.HeldBranch, next []pipelinev1alpha1.HeldBranch) bool {
+	if len(current) != len(next) {
+		return true
+	}
+	for i := range current {
+		if current[i].Key() != next[i].Key() || current[i].Commit != next[i].Commit || current[i].Reason != next[i].Reason || !current[i].SeenTime.Equal(&next[i].SeenTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalsChanged returns true if other pull requests or other commits are approved for testing
+func approvalsChanged(current []pipelinev1alpha1.TestApproval, next []pipelinev1alpha1.TestApproval) bool {
+	if len(current) != len(next) {
+		return true
+	}
+	for i := range current {
+		if current[i].ID != next[i].ID || current[i].Commit != next[i].Commit {
+			return true
+		}
+	}
+	return false
+}
+
+// awaitingOkToTest returns true if any pull request waits for an approval, which does not change the listing of all
+// git providers
+func awaitingOkToTest(held []pipelinev1alpha1.HeldBranch) bool {
+	for i := range held {
+		if held[i].Reason == pipelinev1alpha1.HELD_REASON_AWAITING_OK_TO_TEST {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeld returns true if the pull request was already held back for the same commit
+func isHeld(held []pipelinev1alpha1.HeldBranch, branch pipelinev1alpha1.HeldBranch) bool {
+	for i := range held {
+		if held[i].Key() == branch.Key() && held[i].Commit == branch.Commit {
+			return true
+		}
+	}
+	return false
+}
+
+// validateForkPolicy rejects fork policies, which the git provider cannot enforce
+func validateForkPolicy(provider string, policy *pipelinev1alpha1.ForkPolicy) error {
+	if policy == nil || len(policy.Policy) == 0 || policy.Policy == pipelinev1alpha1.FORK_POLICY_INCLUDE {
+		return nil
+	}
+	if provider != GITHUB_PROVIDER_NAME && provider != BITBUCKET_PROVIDER_NAME {
+		return fmt.Errorf("invalid forks: %s does not detect pull requests from forks", provider)
+	}
+	if provider != GITHUB_PROVIDER_NAME && (len(policy.Organizations) > 0 || len(policy.Teams) > 0) {
+		return fmt.Errorf("invalid forks: %s does not support organizations and teams", provider)
+	}
+	if policy.OkToTest && policy.Policy != pipelinev1alpha1.FORK_POLICY_TRUSTED {
+		return fmt.Errorf("invalid forks: okToTest requires the policy %s", pipelinev1alpha1.FORK_POLICY_TRUSTED)
+	}
+	for _, team := range policy.Teams {
+		if organization, slug, ok := strings.Cut(team, "/"); !ok || len(organization) == 0 || len(slug) == 0 {
+			return fmt.Errorf("invalid forks: the team %s is not given as organization/team-slug", team)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+	gitApi "github.com/jquad-group/pullrequest-operator/pkg/git"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// memberships maps the organizations and the organization/team-slug of the teams to their members
+type memberships struct {
+	members map[string][]string
+	lookups *int
+}
+
+func (memberships memberships) isMember(group string, user string) bool {
+	*memberships.lookups++
+	for _, member := range memberships.members[group] {
+		if member == user {
+			return true
+		}
+	}
+	return false
+}
+
+func (memberships memberships) IsOrganizationMember(ctx context.Context, organization string, user string) (bool, error) {
+	return memberships.isMember(organization, user), nil
+}
+
+func (memberships memberships) IsTeamMember(ctx context.Context, organization string, team string, user string) (bool, error) {
+	return memberships.isMember(organization+"/"+team, user), nil
+}
+
+func newForkBranches() pipelinev1alpha1.Branches {
+	return pipelinev1alpha1.Branches{Branches: []pipelinev1alpha1.Branch{
+		{ID: "1", Name: "feature-a", Author: "maintainer"},
+		{ID: "2", Name: "feature-b", Author: "contributor", Fork: true},
+		{ID: "3", Name: "feature-c", Author: "stranger", Fork: true, Details: `{"number": 3}`},
+		{ID: "4", Name: "feature-d", Author: "reviewer", Fork: true},
+		{ID: "5", Name: "feature-e", Author: "stranger", Fork: true},
+	}}
+}
+
+func TestApplyForkPolicy(t *testing.T) {
+	lookups := 0
+	checker := memberships{lookups: &lookups, members: map[string][]string{
+		"jquad":           {"maintainer"},
+		"jquad/reviewers": {"reviewer"},
+	}}
+	for name, test := range map[string]struct {
+		policy   *pipelinev1alpha1.ForkPolicy
+		included []string
+		reason   string
+	}{
+		"no policy": {nil, []string{"1", "2", "3", "4", "5"}, ""},
+		"include":   {&pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_INCLUDE}, []string{"1", "2", "3", "4", "5"}, ""},
+		"exclude":   {&pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_EXCLUDE, Authors: []string{"contributor"}}, []string{"1"}, pipelinev1alpha1.HELD_REASON_FORK_EXCLUDED},
+		"trusted": {&pipelinev1alpha1.ForkPolicy{
+			Policy:        pipelinev1alpha1.FORK_POLICY_TRUSTED,
+			Authors:       []string{"Contributor"},
+			Organizations: []string{"jquad"},
+			Teams:         []string{"jquad/reviewers"},
+		}, []string{"1", "2", "4"}, pipelinev1alpha1.HELD_REASON_UNTRUSTED_AUTHOR},
+	} {
+		included, held, _, err := applyForkPolicy(context.Background(), test.policy, checker, nil, newForkBranches(), nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if included.GetSize() != len(test.included) {
+			t.Errorf("%s: expected %v to be included, got %+v", name, test.included, included.Branches)
+			continue
+		}
+		for i, id := range test.included {
+			if included.Branches[i].ID != id {
+				t.Errorf("%s: expected %v to be included, got %+v", name, test.included, included.Branches)
+			}
+		}
+		if len(held)+included.GetSize() != 5 {
+			t.Errorf("%s: expected the other pull requests to be held back, got %+v", name, held)
+		}
+		for _, branch := range held {
+			if branch.Reason != test.reason {
+				t.Errorf("%s: expected the reason %s, got %s", name, test.reason, branch.Reason)
+			}
+			if len(branch.Details) > 0 {
+				t.Errorf("%s: expected the details not to be stored with the held pull requests, got %s", name, branch.Details)
+			}
+		}
+	}
+	// the memberships of the untrusted author are looked up once for both pull requests
+	if lookups != 4 {
+		t.Errorf("expected 4 lookups, got %d", lookups)
+	}
+}
+
+// comments maps the pull request IDs to their comments
+type comments map[string][]gitApi.Comment
+
+func (comments comments) ListComments(ctx context.Context, id string) ([]gitApi.Comment, error) {
+	return comments[id], nil
+}
+
+// GetCommitTime returns the zero time, the pushes are not reported like on Github
+func (comments comments) GetCommitTime(ctx context.Context, id string, commit string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// pushedComments looks up the push times of the commits
+type pushedComments struct {
+	comments
+	pushed map[string]time.Time
+}
+
+func (pushedComments pushedComments) GetCommitTime(ctx context.Context, id string, commit string) (time.Time, error) {
+	return pushedComments.pushed[commit], nil
+}
+
+func TestApplyForkPolicyOkToTest(t *testing.T) {
+	lookups := 0
+	checker := memberships{lookups: &lookups, members: map[string][]string{"jquad": {"maintainer"}}}
+	policy := &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_TRUSTED, Organizations: []string{"jquad"}, OkToTest: true}
+	created := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	lister := comments{"2": {
+		{Author: "contributor", Body: "/ok-to-test", Created: created},
+		{Author: "stranger", Body: "/ok-to-test", Created: created.Add(time.Minute)},
+		{Author: "maintainer", Body: "Thanks!\n/ok-to-test", Created: created.Add(2 * time.Minute)},
+	}}
+	branches := pipelinev1alpha1.Branches{Branches: []pipelinev1alpha1.Branch{
+		{ID: "2", Name: "feature-b", Commit: "1111111111111111111111111111111111111111", Author: "contributor", Fork: true},
+		{ID: "3", Name: "feature-c", Commit: "2222222222222222222222222222222222222222", Author: "stranger", Fork: true},
+	}}
+
+	// both pull requests were held back before the comments
+	seen := metav1.NewTime(created.Add(-time.Minute))
+	held := []pipelinev1alpha1.HeldBranch{
+		{Branch: branches.Branches[0], Reason: pipelinev1alpha1.HELD_REASON_AWAITING_OK_TO_TEST, SeenTime: seen},
+		{Branch: branches.Branches[1], Reason: pipelinev1alpha1.HELD_REASON_AWAITING_OK_TO_TEST, SeenTime: seen},
+	}
+
+	included, held, approvals, err := applyForkPolicy(context.Background(), policy, checker, lister, branches, held, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 1 || included.Branches[0].ID != "2" || len(approvals) != 1 || approvals[0].ApprovedBy != "maintainer" || approvals[0].Commit != "1111111111111111111111111111111111111111" {
+		t.Fatalf("expected the pull request approved by the maintainer to be included, got %+v with approvals %+v", included.Branches, approvals)
+	}
+	if len(held) != 1 || held[0].ID != "3" || held[0].Reason != pipelinev1alpha1.HELD_REASON_AWAITING_OK_TO_TEST || !held[0].SeenTime.Equal(&seen) {
+		t.Errorf("expected the other pull request to await the approval, got %+v", held)
+	}
+
+	// a new commit of the author is held back until the maintainer approves it again
+	branches.Branches[0].Commit = "3333333333333333333333333333333333333333"
+	included, held, approvals, err = applyForkPolicy(context.Background(), policy, checker, lister, branches, held, approvals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the approval of the previous commit is kept, so that earlier comments never approve the new commit
+	if included.GetSize() != 0 || len(held) != 2 || len(approvals) != 1 || approvals[0].Commit != "1111111111111111111111111111111111111111" {
+		t.Fatalf("expected the new commit to be held back, got %+v with approvals %+v", included.Branches, approvals)
+	}
+
+	previous := []pipelinev1alpha1.TestApproval{{ID: "2", Commit: "1111111111111111111111111111111111111111", ApprovedBy: "maintainer", ApprovedTime: metav1.NewTime(created.Add(2 * time.Minute))}}
+	held = []pipelinev1alpha1.HeldBranch{{Branch: branches.Branches[0], Reason: pipelinev1alpha1.HELD_REASON_AWAITING_OK_TO_TEST, SeenTime: metav1.NewTime(created.Add(30 * time.Minute))}}
+	lister["2"] = append(lister["2"], gitApi.Comment{Author: "maintainer", Body: "/ok-to-test", Created: created.Add(time.Hour)})
+	included, _, approvals, err = applyForkPolicy(context.Background(), policy, checker, lister, branches, held, previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 1 || len(approvals) != 1 || approvals[0].Commit != "3333333333333333333333333333333333333333" {
+		t.Errorf("expected the new commit to be approved by the later comment, got %+v with approvals %+v", included.Branches, approvals)
+	}
+}
+
+func TestApplyForkPolicyOkToTestBeforePush(t *testing.T) {
+	policy := &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_TRUSTED, Authors: []string{"maintainer"}, OkToTest: true}
+	created := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	lister := pushedComments{
+		comments: comments{"2": {{Author: "maintainer", Body: "/ok-to-test", Created: created}}},
+		pushed:   map[string]time.Time{"3333333333333333333333333333333333333333": created.Add(time.Hour)},
+	}
+	branches := pipelinev1alpha1.Branches{Branches: []pipelinev1alpha1.Branch{
+		{ID: "2", Name: "feature-b", Commit: "3333333333333333333333333333333333333333", Author: "contributor", Fork: true},
+	}}
+
+	// the approval of the previous commit is unknown, e.g. if okToTest was enabled after the comment
+	included, held, approvals, err := applyForkPolicy(context.Background(), policy, nil, lister, branches, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 0 || len(held) != 1 || len(approvals) != 0 {
+		t.Fatalf("expected the comment before the push not to approve the new commit, got %+v with approvals %+v", included.Branches, approvals)
+	}
+
+	lister.comments["2"] = append(lister.comments["2"], gitApi.Comment{Author: "maintainer", Body: "/ok-to-test", Created: created.Add(2 * time.Hour)})
+	included, _, approvals, err = applyForkPolicy(context.Background(), policy, nil, lister, branches, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 1 || len(approvals) != 1 || !approvals[0].ApprovedTime.Equal(&metav1.Time{Time: created.Add(2 * time.Hour)}) {
+		t.Errorf("expected the comment after the push to approve the new commit, got %+v with approvals %+v", included.Branches, approvals)
+	}
+}
+
+func TestApplyForkPolicyOkToTestBackdatedCommit(t *testing.T) {
+	policy := &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_TRUSTED, Authors: []string{"maintainer"}, OkToTest: true}
+	// the maintainer approved a previous commit, the approval is lost because the pull request was closed and reopened
+	lister := comments{"2": {{Author: "maintainer", Body: "/ok-to-test", Created: time.Now().Add(-time.Hour)}}}
+	// the commit date is set before the comment, which Github reports as the date of the commit
+	branches := pipelinev1alpha1.Branches{Branches: []pipelinev1alpha1.Branch{
+		{ID: "2", Name: "feature-b", Commit: "3333333333333333333333333333333333333333", Author: "contributor", Fork: true},
+	}}
+
+	included, held, approvals, err := applyForkPolicy(context.Background(), policy, nil, lister, branches, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 0 || len(held) != 1 || len(approvals) != 0 {
+		t.Fatalf("expected the comment before the commit was seen not to approve it, got %+v with approvals %+v", included.Branches, approvals)
+	}
+	seen := held[0].SeenTime
+
+	// the time the commit was seen first is kept, while the pull request is held back
+	included, held, _, err = applyForkPolicy(context.Background(), policy, nil, lister, branches, held, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 0 || len(held) != 1 || !held[0].SeenTime.Equal(&seen) {
+		t.Fatalf("expected the pull request to be held back since %s, got %+v", seen, held)
+	}
+
+	lister["2"] = append(lister["2"], gitApi.Comment{Author: "maintainer", Body: "/ok-to-test", Created: seen.Add(time.Minute)})
+	included, _, approvals, err = applyForkPolicy(context.Background(), policy, nil, lister, branches, held, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if included.GetSize() != 1 || len(approvals) != 1 {
+		t.Errorf("expected the comment after the commit was seen to approve it, got %+v with approvals %+v", included.Branches, approvals)
+	}
+}
+
+func TestValidateForkPolicy(t *testing.T) {
+	trusted := &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_TRUSTED, Teams: []string{"jquad/reviewers"}}
+	if err := validateForkPolicy(GITHUB_PROVIDER_NAME, trusted); err != nil {
+		t.Errorf("expected teams to be supported by github: %v", err)
+	}
+	if err := validateForkPolicy(BITBUCKET_PROVIDER_NAME, trusted); err == nil {
+		t.Error("expected an error for teams of bitbucket server")
+	}
+	if err := validateForkPolicy(GITLAB_PROVIDER_NAME, &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_EXCLUDE}); err == nil {
+		t.Error("expected an error for a provider without fork detection")
+	}
+	if err := validateForkPolicy(GITLAB_PROVIDER_NAME, &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_INCLUDE}); err != nil {
+		t.Errorf("expected forks to be included by any provider: %v", err)
+	}
+	if err := validateForkPolicy(GITHUB_PROVIDER_NAME, &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_TRUSTED, Teams: []string{"reviewers"}}); err == nil {
+		t.Error("expected an error for a team without organization")
+	}
+	if err := validateForkPolicy(GITHUB_PROVIDER_NAME, &pipelinev1alpha1.ForkPolicy{Policy: pipelinev1alpha1.FORK_POLICY_EXCLUDE, OkToTest: true}); err == nil {
+		t.Error("expected an error for okToTest without the Trusted policy")
+	}
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	gitApi "github.com/jquad-group/pullrequest-operator/pkg/git"
+)
+
+var (
+	rateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pullrequest_operator_ratelimit_remaining",
+		Help: "Number of requests left in the rate limit window of the git provider, as reported with the last poll of the PullRequest",
+	}, []string{"namespace", "name"})
+	rateLimitLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pullrequest_operator_ratelimit_limit",
+		Help: "Number of requests per rate limit window of the git provider, as reported with the last poll of the PullRequest",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(rateLimitRemaining, rateLimitLimit)
+}
+
+func recordRateLimitMetrics(name types.NamespacedName, rateLimit gitApi.RateLimit) {
+	if !rateLimit.Known() {
+		return
+	}
+	rateLimitRemaining.WithLabelValues(name.Namespace, name.Name).Set(float64(rateLimit.Remaining))
+	if rateLimit.Limit > 0 {
+		rateLimitLimit.WithLabelValues(name.Namespace, name.Name).Set(float64(rateLimit.Limit))
+	}
+}
+
+// deleteRateLimitMetrics removes the series of a deleted PullRequest
+func deleteRateLimitMetrics(name types.NamespacedName) {
+	rateLimitRemaining.DeleteLabelValues(name.Namespace, name.Name)
+	rateLimitLimit.DeleteLabelValues(name.Namespace, name.Name)
+}
@@ -18,9 +18,14 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -31,9 +36,12 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
@@ -44,18 +52,49 @@ import (
 const (
 	FIELD_MANAGER = "pullrequest-controller"
 
-	BITBUCKET_PROVIDER_NAME = "Bitbucket"
-	GITHUB_PROVIDER_NAME    = "Github"
+	AZUREDEVOPS_PROVIDER_NAME    = "AzureDevOps"
+	BITBUCKET_PROVIDER_NAME      = "Bitbucket"
+	BITBUCKETCLOUD_PROVIDER_NAME = "BitbucketCloud"
+	GERRIT_PROVIDER_NAME         = "Gerrit"
+	GITHUB_PROVIDER_NAME         = "Github"
+	GITLAB_PROVIDER_NAME         = "Gitlab"
+	GITEA_PROVIDER_NAME          = "Gitea"
 
 	// Status
-	ReconcileUnknown       = "Unknown"
-	ReconcileError         = "Error"
-	ReconcileErrorReason   = "Failed"
+	ReconcileUnknown = "Unknown"
+	// Deprecated: use ConditionReady, the Error condition is removed in the next release
+	ReconcileError       = "Error"
+	ReconcileErrorReason = "Failed"
+	// Deprecated: use ConditionReady, the Success condition is removed in the next release
 	ReconcileSuccess       = "Success"
 	ReconcileSuccessReason = "Succeded"
+	// Reason of the failed conditions, if the git provider rejected the poll because of its rate limit
+	ReconcileRateLimitedReason = "RateLimited"
+
+	// Event reason for pull requests which were closed, merged or declined
+	PullRequestClosedReason = "PullRequestClosed"
+
+	// Retention of closed pull requests in the status, if not specified
+	DEFAULT_CLOSED_RETENTION = 24 * time.Hour
 
 	// Bitbucket and Github Secret Key
 	SECRET_ACCESSTOKEN_KEY = "accessToken"
+	// Optional Secret Key, e.g. for Bitbucket Cloud app passwords and Gerrit HTTP credentials
+	SECRET_USERNAME_KEY = "username"
+	// Github App Secret Keys, used instead of the access token if set
+	SECRET_GITHUB_APP_ID_KEY              = "githubAppID"
+	SECRET_GITHUB_APP_INSTALLATION_ID_KEY = "githubAppInstallationID"
+	SECRET_GITHUB_APP_PRIVATE_KEY_KEY     = "githubAppPrivateKey"
+
+	// Field indexes of the secrets and configmaps referenced by a PullRequest
+	SECRET_INDEX_KEY    = ".spec.gitProvider.secrets"
+	CONFIGMAP_INDEX_KEY = ".spec.gitProvider.configMaps"
+
+	// Key of the CA certificates in the CA bundle secret or configmap, if not specified
+	DEFAULT_CA_BUNDLE_KEY = "ca.crt"
+
+	// Timeout of a poll of the git provider, if not specified
+	DEFAULT_TIMEOUT = 30 * time.Second
 )
 
 // PullRequestReconciler reconciles a PullRequest object
@@ -64,12 +103,17 @@ type PullRequestReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	recorder record.EventRecorder
+
+	// WebhookEvents triggers an immediate reconciliation of a PullRequest, e.g. when the git provider sent a webhook.
+	// Polling stays active as fallback.
+	WebhookEvents <-chan event.GenericEvent
 }
 
 //+kubebuilder:rbac:groups=pipeline.jquad.rocks,resources=pullrequests,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=pipeline.jquad.rocks,resources=pullrequests/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=pipeline.jquad.rocks,resources=pullrequests/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch;update;get;list;watch
 
 func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -77,84 +121,265 @@ func (r *PullRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	var pullrequest pipelinev1alpha1.PullRequest
 	if err := r.Get(ctx, req.NamespacedName, &pullrequest); err != nil {
+		if apierrors.IsNotFound(err) {
+			deleteRateLimitMetrics(req.NamespacedName)
+		}
 		// return and dont requeue
 		return ctrl.Result{}, nil
 	}
 
-	patch := &unstructured.Unstructured{}
-	patch.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   pipelinev1alpha1.GroupVersion.Group,
-		Version: pipelinev1alpha1.GroupVersion.Version,
-		Kind:    "PullRequest",
-	})
-	patch.SetNamespace(pullrequest.GetNamespace())
-	patch.SetName(pullrequest.GetName())
-	patchOptions := &client.PatchOptions{
-		FieldManager: FIELD_MANAGER,
-		Force:        pointer.Bool(true),
+	if err := ValidateSpec(&pullrequest); err != nil {
+		r.recorder.Event(&pullrequest, v1.EventTypeWarning, "Error", err.Error())
+		return r.ManageError(ctx, &pullrequest, req, misconfigured(err))
 	}
 
-	var prPoller gitApi.PullrequestPoller
-	// Credentials for Github/Bitbucket are provided
+	var foundSecret *v1.Secret
+	// Credentials for the git provider are provided
 	if len(pullrequest.Spec.GitProvider.SecretRef) > 0 {
 		// try to find the provided secret on the cluster
-		foundSecret := &v1.Secret{}
+		foundSecret = &v1.Secret{}
 		if err := r.Get(ctx, types.NamespacedName{Name: pullrequest.Spec.GitProvider.SecretRef, Namespace: pullrequest.Namespace}, foundSecret); err != nil {
 			return r.ManageError(ctx, &pullrequest, req, err)
 		}
 		// validate the secret's format
 		if err := Validate(&pullrequest, *foundSecret); err != nil {
 			r.recorder.Event(&pullrequest, v1.EventTypeWarning, "Error", err.Error())
-			return r.ManageError(ctx, &pullrequest, req, err)
+			return r.ManageError(ctx, &pullrequest, req, misconfigured(err))
 		}
-		prPoller = createGitPoller(&pullrequest, string(foundSecret.Data[SECRET_ACCESSTOKEN_KEY]))
-	} else {
-		prPoller = createGitPoller(&pullrequest, "")
-	}
-
-	newBranches, eTag, err := prPoller.Poll(pullrequest.Spec.TargetBranch.Name, pullrequest.Status.ETag)
-	if (eTag == pullrequest.Status.ETag) && (eTag != "") {
-		// Request returned 304 Not Modified, return and requeue at the specified interval
-		return ctrl.Result{RequeueAfter: pullrequest.Spec.Interval.Duration}, nil
 	}
+	transportOptions, err := r.getTransportOptions(ctx, &pullrequest)
 	if err != nil {
 		r.recorder.Event(&pullrequest, v1.EventTypeWarning, "Error", err.Error())
 		return r.ManageError(ctx, &pullrequest, req, err)
 	}
+	// the pollers report the rate limit of the git provider
+	rateLimit := &gitApi.RateLimit{}
+	transportOptions.RateLimit = rateLimit
+	prPoller, err := createGitPoller(&pullrequest, foundSecret, transportOptions)
+	if err != nil {
+		r.recorder.Event(&pullrequest, v1.EventTypeWarning, "Error", err.Error())
+		return r.ManageError(ctx, &pullrequest, req, misconfigured(err))
+	}
 
-	if !pullrequest.Status.SourceBranches.Equals(newBranches) {
-		setDifferences := pullrequest.Status.SourceBranches.BranchSetDifference(newBranches)
-		for i := 0; i < len(setDifferences); i++ {
-			r.recorder.Event(&pullrequest, v1.EventTypeNormal, "Info", "New PR "+setDifferences[i].Name+"/"+setDifferences[i].Commit+" received.")
+	// the context is cancelled on shutdown of the manager, which aborts the requests to the git provider. The timeout
+	// bounds all requests of the reconciliation, i.e. the listing, the memberships, the comments and the states of
+	// closed pull requests.
+	pollCtx, cancel := context.WithTimeout(ctx, transportOptions.Timeout)
+	defer cancel()
+	newBranches, eTag, err := prPoller.Poll(pollCtx, pullrequest.Spec.TargetBranch.Name, pullrequest.Status.ETag)
+	recordRateLimitMetrics(req.NamespacedName, *rateLimit)
+	if err != nil {
+		return r.managePollError(ctx, &pullrequest, req, err)
+	}
+
+	statusRateLimit := toStatusRateLimit(*rateLimit)
+	if newBranches.NotModified && isReady(&pullrequest) && !rateLimitChanged(pullrequest.Status.RateLimit, statusRateLimit) && !closedBranchesExpired(&pullrequest) && !awaitingOkToTest(pullrequest.Status.HeldBranches) {
+		// the pull requests did not change since the last poll of the current generation, the status is not patched
+		return ctrl.Result{RequeueAfter: requeueAfter(pullrequest.Spec.Interval.Duration, *rateLimit)}, nil
+	}
+
+	truncated := newBranches.Truncated
+	// the pull requests from forks of untrusted authors are held back, before they reach the source branches
+	checker, _ := prPoller.(gitApi.MembershipChecker)
+	lister, _ := prPoller.(gitApi.CommentLister)
+	newBranches, heldBranches, approvals, err := applyForkPolicy(pollCtx, pullrequest.Spec.Forks, checker, lister, newBranches, pullrequest.Status.HeldBranches, pullrequest.Status.Approvals)
+	recordRateLimitMetrics(req.NamespacedName, *rateLimit)
+	if err != nil {
+		return r.managePollError(ctx, &pullrequest, req, err)
+	}
+	// the lookups of the memberships count against the rate limit
+	statusRateLimit = toStatusRateLimit(*rateLimit)
+
+	added, removed, updated := pullrequest.Status.SourceBranches.Diff(newBranches)
+	// the details are only stored once with the snapshot, to keep the status below the size limit of etcd
+	added, removed, updated = withoutDetails(added), withoutDetails(removed), withoutDetails(updated)
+	closedBranches, err := r.closeBranches(pollCtx, &pullrequest, prPoller, removed)
+	if err != nil {
+		return r.managePollError(ctx, &pullrequest, req, err)
+	}
+	if len(added) > 0 || len(removed) > 0 || len(updated) > 0 || len(closedBranches) != len(pullrequest.Status.ClosedBranches) || draftsChanged(pullrequest.Status.SourceBranches, newBranches) || heldBranchesChanged(pullrequest.Status.HeldBranches, heldBranches) || approvalsChanged(pullrequest.Status.Approvals, approvals) || truncated != pullrequest.Status.Truncated || !isReady(&pullrequest) || pullrequest.Status.ConsecutiveFailures > 0 || rateLimitChanged(pullrequest.Status.RateLimit, statusRateLimit) {
+		for i := 0; i < len(added); i++ {
+			r.recorder.Event(&pullrequest, v1.EventTypeNormal, "Info", "New PR "+added[i].Name+"/"+added[i].Commit+" received.")
+		}
+		for i := 0; i < len(updated); i++ {
+			r.recorder.Event(&pullrequest, v1.EventTypeNormal, "Info", "Updated PR "+updated[i].Name+"/"+updated[i].Commit+" received.")
+		}
+		for i := 0; i < len(heldBranches); i++ {
+			if !isHeld(pullrequest.Status.HeldBranches, heldBranches[i]) {
+				r.recorder.Event(&pullrequest, v1.EventTypeWarning, heldBranches[i].Reason, "PR "+heldBranches[i].Name+"/"+heldBranches[i].Commit+" from a fork is held back.")
+			}
 		}
-		condition := metav1.Condition{
-			Type:               ReconcileSuccess,
-			LastTransitionTime: metav1.Now(),
-			ObservedGeneration: pullrequest.GetGeneration(),
-			Reason:             ReconcileSuccessReason,
-			Status:             metav1.ConditionTrue,
-			Message:            "Success",
+		for i := 0; i < len(approvals); i++ {
+			if previous := findApproval(pullrequest.Status.Approvals, approvals[i].ID); previous == nil || previous.Commit != approvals[i].Commit {
+				r.recorder.Event(&pullrequest, v1.EventTypeNormal, "OkToTest", "PR "+approvals[i].ID+"/"+approvals[i].Commit+" approved for testing by "+approvals[i].ApprovedBy+".")
+			}
 		}
-		pullrequest.AddOrReplaceCondition(condition)
+		// the warning is recorded once, when further pull requests are cut off for the first time
+		if truncated && !pullrequest.Status.Truncated {
+			r.recorder.Event(&pullrequest, v1.EventTypeWarning, "Truncated", fmt.Sprintf("The number of open PRs reached the limit of %d, further PRs are ignored.", pullrequest.Spec.MaxPullRequests))
+		}
+		markReady(&pullrequest)
+		pullrequest.Status.ConsecutiveFailures = 0
 		pullrequest.Status.ETag = eTag
-		pullrequest.Status.SourceBranches.Branches = setDifferences
-		patch.UnstructuredContent()["status"] = pullrequest.Status
-		r.Status().Patch(ctx, patch, client.Apply, patchOptions)
+		// the status holds the complete snapshot of open PRs, the deltas describe the changes to the previous snapshot
+		pullrequest.Status.SourceBranches.Branches = newBranches.Branches
+		pullrequest.Status.AddedBranches = added
+		pullrequest.Status.RemovedBranches = removed
+		pullrequest.Status.UpdatedBranches = updated
+		pullrequest.Status.ClosedBranches = closedBranches
+		pullrequest.Status.HeldBranches = heldBranches
+		pullrequest.Status.Truncated = truncated
+		pullrequest.Status.Approvals = approvals
+		pullrequest.Status.RateLimit = statusRateLimit
+		r.patchStatus(ctx, &pullrequest)
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter(pullrequest.Spec.Interval.Duration, *rateLimit)}, nil
+}
+
+// managePollError requeues the PullRequest at the reset of the rate limit, if the git provider rejected a request
+// because of the rate limit, and handles other errors by ManageError
+func (r *PullRequestReconciler) managePollError(ctx context.Context, pullrequest *pipelinev1alpha1.PullRequest, req ctrl.Request, err error) (ctrl.Result, error) {
+	var rateLimitErr *gitApi.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		r.recorder.Event(pullrequest, v1.EventTypeWarning, ReconcileRateLimitedReason, err.Error())
+		return r.ManageRateLimit(ctx, pullrequest, rateLimitErr)
 	}
+	r.recorder.Event(pullrequest, v1.EventTypeWarning, "Error", err.Error())
+	return r.ManageError(ctx, pullrequest, req, err)
+}
+
+// withoutDetails returns copies of the branches without the details
+func withoutDetails(branches []pipelinev1alpha1.Branch) []pipelinev1alpha1.Branch {
+	if branches == nil {
+		return nil
+	}
+	result := make([]pipelinev1alpha1.Branch, len(branches))
+	for i := range branches {
+		result[i] = branches[i].WithoutDetails()
+	}
+	return result
+}
+
+// draftsChanged returns true if a pull request was converted to a draft, which is not reported as added or updated
+func draftsChanged(current pipelinev1alpha1.Branches, next pipelinev1alpha1.Branches) bool {
+	drafts := make(map[string]bool, current.GetSize())
+	for _, branch := range current.Branches {
+		drafts[branch.Key()] = branch.Draft
+	}
+	for _, branch := range next.Branches {
+		if draft, ok := drafts[branch.Key()]; ok && draft != branch.Draft {
+			return true
+		}
+	}
+	return false
+}
+
+// requeueAfter delays the next poll until the rate limit resets, if no requests are left
+func requeueAfter(interval time.Duration, rateLimit gitApi.RateLimit) time.Duration {
+	now := time.Now()
+	if rateLimit.Exhausted(now) && rateLimit.Reset.Sub(now) > interval {
+		return rateLimit.Reset.Sub(now)
+	}
+	return interval
+}
 
-	return ctrl.Result{RequeueAfter: pullrequest.Spec.Interval.Duration}, nil
+func toStatusRateLimit(rateLimit gitApi.RateLimit) *pipelinev1alpha1.RateLimit {
+	if !rateLimit.Known() {
+		return nil
+	}
+	statusRateLimit := &pipelinev1alpha1.RateLimit{Limit: rateLimit.Limit, Remaining: rateLimit.Remaining}
+	if !rateLimit.Reset.IsZero() {
+		statusRateLimit.Reset = &metav1.Time{Time: rateLimit.Reset}
+	}
+	return statusRateLimit
+}
+
+// rateLimitChanged returns true if the rate limit window was reset or the requests are used up. The remaining requests
+// alone do not trigger a status update, as they change with every poll.
+func rateLimitChanged(old *pipelinev1alpha1.RateLimit, new *pipelinev1alpha1.RateLimit) bool {
+	if old == nil || new == nil {
+		return old != new
+	}
+	if old.Limit != new.Limit || (new.Remaining == 0 && old.Remaining != 0) {
+		return true
+	}
+	if old.Reset == nil || new.Reset == nil {
+		return old.Reset != new.Reset
+	}
+	// the status holds the reset time with a precision of seconds
+	return old.Reset.Unix() != new.Reset.Unix()
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PullRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("PullRequest")
 
-	return ctrl.NewControllerManagedBy(mgr).
+	// index the referenced secrets and configmaps, so that a changed secret or configmap triggers a reconciliation
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &pipelinev1alpha1.PullRequest{}, SECRET_INDEX_KEY, func(obj client.Object) []string {
+		return referencedSecrets(obj.(*pipelinev1alpha1.PullRequest))
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &pipelinev1alpha1.PullRequest{}, CONFIGMAP_INDEX_KEY, func(obj client.Object) []string {
+		caBundle := obj.(*pipelinev1alpha1.PullRequest).Spec.GitProvider.CABundle
+		if caBundle == nil || len(caBundle.ConfigMapRef) == 0 {
+			return nil
+		}
+		return []string{caBundle.ConfigMapRef}
+	}); err != nil {
+		return err
+	}
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&pipelinev1alpha1.PullRequest{},
 			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
-		Complete(r)
+		Watches(&source.Kind{Type: &v1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.findPullRequestsReferencing(SECRET_INDEX_KEY)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(&source.Kind{Type: &v1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.findPullRequestsReferencing(CONFIGMAP_INDEX_KEY)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}))
+	if r.WebhookEvents != nil {
+		controllerBuilder = controllerBuilder.Watches(&source.Channel{Source: r.WebhookEvents}, &handler.EnqueueRequestForObject{})
+	}
+	return controllerBuilder.Complete(r)
 }
 
+// referencedSecrets returns the names of the credentials, CA bundle and client certificate secrets of the PullRequest
+func referencedSecrets(pullrequest *pipelinev1alpha1.PullRequest) []string {
+	var secrets []string
+	gitProvider := pullrequest.Spec.GitProvider
+	if len(gitProvider.SecretRef) > 0 {
+		secrets = append(secrets, gitProvider.SecretRef)
+	}
+	if gitProvider.CABundle != nil && len(gitProvider.CABundle.SecretRef) > 0 {
+		secrets = append(secrets, gitProvider.CABundle.SecretRef)
+	}
+	if len(gitProvider.ClientCertSecretRef) > 0 {
+		secrets = append(secrets, gitProvider.ClientCertSecretRef)
+	}
+	return secrets
+}
+
+// findPullRequestsReferencing maps a secret or configmap to the PullRequests of its namespace referencing it
+func (r *PullRequestReconciler) findPullRequestsReferencing(indexKey string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		var pullrequests pipelinev1alpha1.PullRequestList
+		if err := r.List(context.Background(), &pullrequests, client.InNamespace(obj.GetNamespace()), client.MatchingFields{indexKey: obj.GetName()}); err != nil {
+			return nil
+		}
+		requests := make([]reconcile.Request, len(pullrequests.Items))
+		for i := range pullrequests.Items {
+			requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: pullrequests.Items[i].Name, Namespace: pullrequests.Items[i].Namespace}}
+		}
+		return requests
+	}
+}
+
+// ManageError records the error in the status. Permanent errors, e.g. invalid credentials or a repository which does
+// not exist, stall the reconciliation until the spec or the secret changes. Transient errors are retried with an
+// exponential backoff.
 func (r *PullRequestReconciler) ManageError(context context.Context, obj *pipelinev1alpha1.PullRequest, req ctrl.Request, message error) (reconcile.Result, error) {
 	log := log.FromContext(context)
 	if err := r.Get(context, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, obj); err != nil {
@@ -162,36 +387,304 @@ func (r *PullRequestReconciler) ManageError(context context.Context, obj *pipeli
 		return reconcile.Result{}, err
 	}
 
-	condition := metav1.Condition{
-		Type:               ReconcileError,
-		LastTransitionTime: metav1.Now(),
-		ObservedGeneration: obj.GetGeneration(),
-		Reason:             ReconcileErrorReason,
-		Status:             metav1.ConditionFalse,
-		Message:            message.Error(),
+	obj.Status.ConsecutiveFailures++
+
+	result := reconcile.Result{RequeueAfter: backoff(obj.Status.ConsecutiveFailures)}
+	if reason, stalled := classifyError(message); stalled {
+		markFailed(obj, reason, message.Error(), true)
+		// the watches of the PullRequest and the referenced secrets trigger the next reconciliation
+		result = reconcile.Result{}
+	} else {
+		markFailed(obj, ReconcileErrorReason, message.Error(), false)
 	}
-	obj.AddOrReplaceCondition(condition)
-	err := r.Status().Update(context, obj)
-	if err != nil {
+
+	if err := r.patchStatus(context, obj); err != nil {
+		log.Error(err, "unable to update status")
+		return reconcile.Result{}, err
+	}
+	return result, nil
+}
+
+// ManageRateLimit records the rate limit error and requeues the PullRequest when the rate limit resets, instead of
+// retrying immediately
+func (r *PullRequestReconciler) ManageRateLimit(context context.Context, obj *pipelinev1alpha1.PullRequest, rateLimitErr *gitApi.RateLimitError) (reconcile.Result, error) {
+	log := log.FromContext(context)
+	if err := r.Get(context, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, obj); err != nil {
+		log.Error(err, "unable to get obj")
+		return reconcile.Result{}, err
+	}
+
+	markFailed(obj, ReconcileRateLimitedReason, rateLimitErr.Error(), false)
+	obj.Status.RateLimit = toStatusRateLimit(rateLimitErr.RateLimit)
+	if err := r.patchStatus(context, obj); err != nil {
 		log.Error(err, "unable to update status")
 		return reconcile.Result{}, err
 	}
-	return reconcile.Result{Requeue: true}, nil
+
+	// the reset is unknown, e.g. if a secondary rate limit did not specify a delay
+	delay := time.Until(rateLimitErr.RateLimit.Reset)
+	if delay <= 0 {
+		delay = obj.Spec.Interval.Duration
+	}
+	return reconcile.Result{RequeueAfter: delay}, nil
+}
+
+// patchStatus applies the status of the PullRequest, fields which are no longer set are removed
+func (r *PullRequestReconciler) patchStatus(ctx context.Context, pullrequest *pipelinev1alpha1.PullRequest) error {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   pipelinev1alpha1.GroupVersion.Group,
+		Version: pipelinev1alpha1.GroupVersion.Version,
+		Kind:    "PullRequest",
+	})
+	patch.SetNamespace(pullrequest.GetNamespace())
+	patch.SetName(pullrequest.GetName())
+	patch.UnstructuredContent()["status"] = pullrequest.Status
+	patchOptions := &client.PatchOptions{
+		FieldManager: FIELD_MANAGER,
+		Force:        pointer.Bool(true),
+	}
+	return r.Status().Patch(ctx, patch, client.Apply, patchOptions)
+}
+
+func closedRetention(pullrequest *pipelinev1alpha1.PullRequest) time.Duration {
+	if pullrequest.Spec.ClosedRetention != nil {
+		return pullrequest.Spec.ClosedRetention.Duration
+	}
+	return DEFAULT_CLOSED_RETENTION
+}
+
+// closedBranchesExpired returns true if a closed pull request in the status is past the retention window
+func closedBranchesExpired(pullrequest *pipelinev1alpha1.PullRequest) bool {
+	for _, closedBranch := range pullrequest.Status.ClosedBranches {
+		if time.Since(closedBranch.ClosedTime.Time) >= closedRetention(pullrequest) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeBranches looks up the final state of the removed pull requests, emits an event for each closed pull request and
+// returns the closed pull requests within the retention window
+func (r *PullRequestReconciler) closeBranches(ctx context.Context, pullrequest *pipelinev1alpha1.PullRequest, prPoller gitApi.PullrequestPoller, removed []pipelinev1alpha1.Branch) ([]pipelinev1alpha1.ClosedBranch, error) {
+	log := log.FromContext(ctx)
+
+	retention := closedRetention(pullrequest)
+
+	var closedBranches []pipelinev1alpha1.ClosedBranch
+	for _, closedBranch := range pullrequest.Status.ClosedBranches {
+		if time.Since(closedBranch.ClosedTime.Time) < retention {
+			closedBranches = append(closedBranches, closedBranch)
+		}
+	}
+
+	for _, branch := range removed {
+		state := pipelinev1alpha1.PULLREQUEST_STATE_CLOSED
+		mergeCommit := ""
+		if len(branch.ID) > 0 {
+			var err error
+			state, mergeCommit, err = prPoller.GetState(ctx, branch.ID)
+			if ctx.Err() != nil {
+				// the state is unknown, the pull requests are closed again with the next reconciliation
+				return nil, err
+			}
+			if err != nil {
+				// e.g. the pull request was deleted
+				log.Error(err, "unable to get the state of the pull request", "id", branch.ID)
+				state = pipelinev1alpha1.PULLREQUEST_STATE_CLOSED
+				mergeCommit = ""
+			}
+		}
+		if state == pipelinev1alpha1.PULLREQUEST_STATE_OPEN {
+			// still open, but no longer listed, e.g. because the target branch of the pull request changed
+			continue
+		}
+
+		message := "PR " + branch.Name + "/" + branch.Commit + " " + state + "."
+		if len(mergeCommit) > 0 {
+			message = "PR " + branch.Name + "/" + branch.Commit + " " + state + " with merge commit " + mergeCommit + "."
+		}
+		r.recorder.Event(pullrequest, v1.EventTypeNormal, PullRequestClosedReason, message)
+
+		if retention > 0 {
+			closedBranches = append(closedBranches, pipelinev1alpha1.ClosedBranch{
+				Branch:      branch,
+				State:       state,
+				MergeCommit: mergeCommit,
+				ClosedTime:  metav1.Now(),
+			})
+		}
+	}
+
+	return closedBranches, nil
 }
 
 func Validate(pullrequest *pipelinev1alpha1.PullRequest, secret v1.Secret) error {
+	if pullrequest.Spec.GitProvider.Provider == GITHUB_PROVIDER_NAME && hasGithubApp(secret) {
+		for _, key := range []string{SECRET_GITHUB_APP_ID_KEY, SECRET_GITHUB_APP_INSTALLATION_ID_KEY, SECRET_GITHUB_APP_PRIVATE_KEY_KEY} {
+			if len(secret.Data[key]) <= 0 {
+				return fmt.Errorf("invalid Github App auth option: '%s', '%s' and '%s' must be set", SECRET_GITHUB_APP_ID_KEY, SECRET_GITHUB_APP_INSTALLATION_ID_KEY, SECRET_GITHUB_APP_PRIVATE_KEY_KEY)
+			}
+		}
+		return nil
+	}
 	if len(secret.Data[SECRET_ACCESSTOKEN_KEY]) <= 0 {
 		return fmt.Errorf("invalid HTTP auth option: 'accessToken' must be set")
 	}
+	// the access token of Gerrit is the HTTP password of a user
+	if pullrequest.Spec.GitProvider.Provider == GERRIT_PROVIDER_NAME && len(secret.Data[SECRET_USERNAME_KEY]) <= 0 {
+		return fmt.Errorf("invalid HTTP auth option: 'username' must be set for %s", GERRIT_PROVIDER_NAME)
+	}
 	return nil
 }
 
-func createGitPoller(repo *pipelinev1alpha1.PullRequest, accessToken string) gitApi.PullrequestPoller {
+// getTransportOptions reads the proxy, the CA bundle and the client certificate referenced by the git provider
+func (r *PullRequestReconciler) getTransportOptions(ctx context.Context, pullrequest *pipelinev1alpha1.PullRequest) (gitApi.TransportOptions, error) {
+	gitProvider := pullrequest.Spec.GitProvider
+	transportOptions := gitApi.TransportOptions{
+		TLSOptions: gitApi.TLSOptions{InsecureSkipVerify: gitProvider.InsecureSkipVerify},
+		Proxy:      gitProvider.Proxy,
+		Timeout:    DEFAULT_TIMEOUT,
+	}
+	if gitProvider.Timeout != nil && gitProvider.Timeout.Duration > 0 {
+		transportOptions.Timeout = gitProvider.Timeout.Duration
+	}
+
+	if caBundle := gitProvider.CABundle; caBundle != nil {
+		key := caBundle.Key
+		if len(key) == 0 {
+			key = DEFAULT_CA_BUNDLE_KEY
+		}
+		switch {
+		case len(caBundle.SecretRef) > 0:
+			secret := &v1.Secret{}
+			if err := r.Get(ctx, types.NamespacedName{Name: caBundle.SecretRef, Namespace: pullrequest.Namespace}, secret); err != nil {
+				return transportOptions, err
+			}
+			transportOptions.CABundle = secret.Data[key]
+		case len(caBundle.ConfigMapRef) > 0:
+			configMap := &v1.ConfigMap{}
+			if err := r.Get(ctx, types.NamespacedName{Name: caBundle.ConfigMapRef, Namespace: pullrequest.Namespace}, configMap); err != nil {
+				return transportOptions, err
+			}
+			transportOptions.CABundle = []byte(configMap.Data[key])
+		default:
+			return transportOptions, misconfigured(fmt.Errorf("invalid CA bundle: either 'secretRef' or 'configMapRef' must be set"))
+		}
+		if len(transportOptions.CABundle) == 0 {
+			return transportOptions, misconfigured(fmt.Errorf("invalid CA bundle: key '%s' is not set", key))
+		}
+	}
+
+	if len(gitProvider.ClientCertSecretRef) > 0 {
+		secret := &v1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: gitProvider.ClientCertSecretRef, Namespace: pullrequest.Namespace}, secret); err != nil {
+			return transportOptions, err
+		}
+		transportOptions.ClientCert = secret.Data[v1.TLSCertKey]
+		transportOptions.ClientKey = secret.Data[v1.TLSPrivateKeyKey]
+	}
+
+	if err := transportOptions.Validate(); err != nil {
+		return transportOptions, misconfigured(err)
+	}
+	return transportOptions, nil
+}
+
+// hasGithubApp returns true if the secret contains any of the Github App keys
+func hasGithubApp(secret v1.Secret) bool {
+	return len(secret.Data[SECRET_GITHUB_APP_ID_KEY]) > 0 || len(secret.Data[SECRET_GITHUB_APP_INSTALLATION_ID_KEY]) > 0 || len(secret.Data[SECRET_GITHUB_APP_PRIVATE_KEY_KEY]) > 0
+}
+
+// createGitPoller creates the shared poller of the git provider, authenticated with the credentials of the secret, if any
+func createGitPoller(repo *pipelinev1alpha1.PullRequest, secret *v1.Secret, transportOptions gitApi.TransportOptions) (gitApi.PullrequestPoller, error) {
+	var username, accessToken string
+	if secret != nil {
+		username = string(secret.Data[SECRET_USERNAME_KEY])
+		accessToken = string(secret.Data[SECRET_ACCESSTOKEN_KEY])
+	}
+
+	// the pull requests of all target branches are listed without limit, the shared poller filters and bounds them
+	var poller gitApi.PullrequestPoller
 	switch repo.Spec.GitProvider.Provider {
+	case AZUREDEVOPS_PROVIDER_NAME:
+		poller = gitApi.NewAzureDevOpsPoller(repo.Spec.GitProvider.AzureDevOps.Url, accessToken, transportOptions, repo.Spec.GitProvider.AzureDevOps.Organization, repo.Spec.GitProvider.AzureDevOps.Project, repo.Spec.GitProvider.AzureDevOps.Repository)
+	case GERRIT_PROVIDER_NAME:
+		poller = gitApi.NewGerritPoller(repo.Spec.GitProvider.Gerrit.Url, username, accessToken, transportOptions, repo.Spec.GitProvider.Gerrit.Project)
 	case GITHUB_PROVIDER_NAME:
-		return gitApi.NewGithubPoller(repo.Spec.GitProvider.Github.Url, accessToken, repo.Spec.GitProvider.InsecureSkipVerify, repo.Spec.GitProvider.Github.Owner, repo.Spec.GitProvider.Github.Repository)
+		// the auth mode is picked by the keys of the secret
+		if secret != nil && hasGithubApp(*secret) {
+			app, err := gitApi.NewGithubApp(strings.TrimSpace(string(secret.Data[SECRET_GITHUB_APP_ID_KEY])), strings.TrimSpace(string(secret.Data[SECRET_GITHUB_APP_INSTALLATION_ID_KEY])), secret.Data[SECRET_GITHUB_APP_PRIVATE_KEY_KEY])
+			if err != nil {
+				return nil, err
+			}
+			poller = gitApi.NewGithubAppPoller(repo.Spec.GitProvider.Github.Url, app, transportOptions, repo.Spec.GitProvider.Github.Owner, repo.Spec.GitProvider.Github.Repository)
+		} else {
+			poller = gitApi.NewGithubPoller(repo.Spec.GitProvider.Github.Url, accessToken, transportOptions, repo.Spec.GitProvider.Github.Owner, repo.Spec.GitProvider.Github.Repository)
+		}
 	case BITBUCKET_PROVIDER_NAME:
-		return gitApi.NewBitbucketPoller(repo.Spec.GitProvider.Bitbucket.RestEndpoint, accessToken, repo.Spec.GitProvider.InsecureSkipVerify, repo.Spec.GitProvider.Bitbucket.Project, repo.Spec.GitProvider.Bitbucket.Repository)
+		poller = gitApi.NewBitbucketPoller(repo.Spec.GitProvider.Bitbucket.RestEndpoint, accessToken, transportOptions, repo.Spec.GitProvider.Bitbucket.Project, repo.Spec.GitProvider.Bitbucket.Repository)
+	case BITBUCKETCLOUD_PROVIDER_NAME:
+		poller = gitApi.NewBitbucketCloudPoller(repo.Spec.GitProvider.BitbucketCloud.Url, username, accessToken, transportOptions, repo.Spec.GitProvider.BitbucketCloud.Workspace, repo.Spec.GitProvider.BitbucketCloud.Repository)
+	case GITLAB_PROVIDER_NAME:
+		poller = gitApi.NewGitlabPoller(repo.Spec.GitProvider.Gitlab.Url, accessToken, transportOptions, repo.Spec.GitProvider.Gitlab.Project)
+	case GITEA_PROVIDER_NAME:
+		poller = gitApi.NewGiteaPoller(repo.Spec.GitProvider.Gitea.Url, accessToken, transportOptions, repo.Spec.GitProvider.Gitea.Owner, repo.Spec.GitProvider.Gitea.Repository)
+	default:
+		return nil, fmt.Errorf("unsupported git provider %s", repo.Spec.GitProvider.Provider)
 	}
-	return nil
+
+	// all PullRequests watching the same repository with the same credentials share the listed pull requests
+	sharedPoller := gitApi.NewSharedPoller(gitApi.RepositoryKey(repo.Spec.GitProvider), credentials(secret), transportOptions, poller, repo.Spec.Interval.Duration, repo.Spec.MaxPullRequests)
+	sharedPoller.Filter = branchFilter(repo)
+	return sharedPoller, nil
+}
+
+// branchFilter selects the pull requests matching the filters of the spec
+func branchFilter(repo *pipelinev1alpha1.PullRequest) func(pipelinev1alpha1.Branch) bool {
+	return func(branch pipelinev1alpha1.Branch) bool {
+		if branch.Draft && !repo.Spec.IncludeDrafts {
+			return false
+		}
+		if repo.Spec.Labels != nil && !repo.Spec.Labels.Matches(branch.Labels) {
+			return false
+		}
+		if repo.Spec.SourceBranches != nil && !repo.Spec.SourceBranches.Matches(branch.Name) {
+			return false
+		}
+		return true
+	}
+}
+
+// ValidateSpec rejects invalid filters and filters, which are not supported by the git provider
+func ValidateSpec(pullrequest *pipelinev1alpha1.PullRequest) error {
+	switch pullrequest.Spec.GitProvider.Provider {
+	case BITBUCKET_PROVIDER_NAME, BITBUCKETCLOUD_PROVIDER_NAME:
+		if labels := pullrequest.Spec.Labels; labels != nil && (len(labels.Include) > 0 || len(labels.Exclude) > 0) {
+			return fmt.Errorf("invalid labels: %s does not support labels", pullrequest.Spec.GitProvider.Provider)
+		}
+	}
+	if pullrequest.Spec.SourceBranches != nil {
+		if err := pullrequest.Spec.SourceBranches.Validate(); err != nil {
+			return err
+		}
+	}
+	return validateForkPolicy(pullrequest.Spec.GitProvider.Provider, pullrequest.Spec.Forks)
+}
+
+// credentials concatenates the data of the secret, so that only PullRequests with the same credentials share polls
+func credentials(secret *v1.Secret) string {
+	if secret == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var credentials strings.Builder
+	for _, key := range keys {
+		credentials.WriteString(key + "=" + string(secret.Data[key]) + "\n")
+	}
+	return credentials.String()
 }
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+func TestValidate(t *testing.T) {
+	for name, test := range map[string]struct {
+		provider string
+		data     map[string][]byte
+		valid    bool
+	}{
+		"access token":            {GITHUB_PROVIDER_NAME, map[string][]byte{SECRET_ACCESSTOKEN_KEY: []byte("token")}, true},
+		"missing access token":    {GITLAB_PROVIDER_NAME, map[string][]byte{SECRET_USERNAME_KEY: []byte("jquad")}, false},
+		"gerrit http password":    {GERRIT_PROVIDER_NAME, map[string][]byte{SECRET_USERNAME_KEY: []byte("jquad"), SECRET_ACCESSTOKEN_KEY: []byte("secret")}, true},
+		"gerrit without username": {GERRIT_PROVIDER_NAME, map[string][]byte{SECRET_ACCESSTOKEN_KEY: []byte("secret")}, false},
+		"incomplete github app":   {GITHUB_PROVIDER_NAME, map[string][]byte{SECRET_GITHUB_APP_ID_KEY: []byte("1234")}, false},
+	} {
+		pullrequest := &pipelinev1alpha1.PullRequest{Spec: pipelinev1alpha1.PullRequestSpec{GitProvider: pipelinev1alpha1.GitProvider{Provider: test.provider}}}
+		err := Validate(pullrequest, v1.Secret{Data: test.data})
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -28,11 +28,13 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
 	"github.com/jquad-group/pullrequest-operator/controllers"
+	"github.com/jquad-group/pullrequest-operator/pkg/webhook"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -52,8 +54,11 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var webhookAddr string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&webhookAddr, "webhook-bind-address", "", "The address the git provider webhook receiver binds to. "+
+		"The receiver is disabled if empty.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -78,9 +83,23 @@ func main() {
 		os.Exit(1)
 	}
 
+	var webhookEvents chan event.GenericEvent
+	if len(webhookAddr) > 0 {
+		webhookEvents = make(chan event.GenericEvent, 1024)
+		if err := mgr.Add(&webhook.Receiver{
+			Client: mgr.GetClient(),
+			Addr:   webhookAddr,
+			Events: webhookEvents,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up webhook receiver")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controllers.PullRequestReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		WebhookEvents: webhookEvents,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PullRequest")
 		os.Exit(1)
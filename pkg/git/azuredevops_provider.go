@@ -0,0 +1,171 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+const (
+	azureDevOpsDefaultEndpoint = "https://dev.azure.com"
+	azureDevOpsApiVersion      = "7.0"
+	azureDevOpsPageSize        = 100
+)
+
+type AzureDevOpsPoller struct {
+	Endpoint     string
+	AccessToken  string
+	Transport    TransportOptions
+	Organization string
+	Project      string
+	Repository   string
+}
+
+type azureDevOpsPullRequestList struct {
+	Value []json.RawMessage `json:"value"`
+	Count int               `json:"count"`
+}
+
+type azureDevOpsPullRequest struct {
+	PullRequestId         int    `json:"pullRequestId"`
+	Status                string `json:"status"`
+	SourceRefName         string `json:"sourceRefName"`
+	TargetRefName         string `json:"targetRefName"`
+	LastMergeSourceCommit struct {
+		CommitId string `json:"commitId"`
+	} `json:"lastMergeSourceCommit"`
+	LastMergeCommit struct {
+		CommitId string `json:"commitId"`
+	} `json:"lastMergeCommit"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	IsDraft bool `json:"isDraft"`
+}
+
+func NewAzureDevOpsPoller(endpoint string, accessToken string, transportOptions TransportOptions, organization string, project string, repository string) *AzureDevOpsPoller {
+	if len(endpoint) == 0 {
+		endpoint = azureDevOpsDefaultEndpoint
+	}
+	return &AzureDevOpsPoller{
+		Endpoint:     endpoint,
+		AccessToken:  accessToken,
+		Transport:    transportOptions,
+		Organization: organization,
+		Project:      project,
+		Repository:   repository,
+	}
+}
+
+func (azureDevOpsPoller AzureDevOpsPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	httpClient, err := newHTTPClient(azureDevOpsPoller.Transport)
+	if err != nil {
+		return pullrequestv1alpha1.Branches{}, "", err
+	}
+
+	var branches pullrequestv1alpha1.Branches
+
+	baseUrl, err := url.Parse(azureDevOpsPoller.pullRequestsUrl())
+	if err != nil {
+		return branches, "", err
+	}
+
+	var sourceBranches []pullrequestv1alpha1.Branch
+	for skip := 0; ; skip += azureDevOpsPageSize {
+		query := url.Values{}
+		query.Set("searchCriteria.status", "active")
+		// the target ref has to be a fully qualified ref, e.g. refs/heads/main, without a branch the pull requests of
+		// all target branches are listed
+		if len(branch) > 0 {
+			query.Set("searchCriteria.targetRefName", "refs/heads/"+trimBranchRef(branch))
+		}
+		query.Set("$top", strconv.Itoa(azureDevOpsPageSize))
+		query.Set("$skip", strconv.Itoa(skip))
+		query.Set("api-version", azureDevOpsApiVersion)
+		baseUrl.RawQuery = query.Encode()
+
+		req, err := azureDevOpsPoller.newRequest(ctx, baseUrl.String())
+		if err != nil {
+			return branches, "", err
+		}
+
+		var prList azureDevOpsPullRequestList
+		err = getJSON(httpClient, req, &prList)
+		if err != nil {
+			return branches, "", err
+		}
+
+		for i := 0; i < len(prList.Value); i++ {
+			var pr azureDevOpsPullRequest
+			if err := json.Unmarshal(prList.Value[i], &pr); err != nil {
+				return branches, "", err
+			}
+			var tempBranch pullrequestv1alpha1.Branch
+			tempBranch.ID = strconv.Itoa(pr.PullRequestId)
+			tempBranch.Name = trimBranchRef(pr.SourceRefName)
+			tempBranch.Commit = pr.LastMergeSourceCommit.CommitId
+			tempBranch.TargetBranch = trimBranchRef(pr.TargetRefName)
+			tempBranch.Draft = pr.IsDraft
+			for _, label := range pr.Labels {
+				tempBranch.Labels = append(tempBranch.Labels, label.Name)
+			}
+			tempBranch.Details = string(prList.Value[i])
+			sourceBranches = append(sourceBranches, tempBranch)
+		}
+
+		if len(prList.Value) < azureDevOpsPageSize {
+			break
+		}
+	}
+
+	branches.Branches = sourceBranches
+
+	return branches, "", nil
+}
+
+func (azureDevOpsPoller AzureDevOpsPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	httpClient, err := newHTTPClient(azureDevOpsPoller.Transport)
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := azureDevOpsPoller.newRequest(ctx, azureDevOpsPoller.pullRequestsUrl()+"/"+url.PathEscape(id)+"?api-version="+azureDevOpsApiVersion)
+	if err != nil {
+		return "", "", err
+	}
+	var pr azureDevOpsPullRequest
+	if err := getJSON(httpClient, req, &pr); err != nil {
+		return "", "", err
+	}
+
+	switch pr.Status {
+	case "active":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	case "completed":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, pr.LastMergeCommit.CommitId, nil
+	case "abandoned":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_DECLINED, "", nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+func (azureDevOpsPoller AzureDevOpsPoller) pullRequestsUrl() string {
+	return strings.TrimSuffix(azureDevOpsPoller.Endpoint, "/") + "/" + url.PathEscape(azureDevOpsPoller.Organization) + "/" + url.PathEscape(azureDevOpsPoller.Project) + "/_apis/git/repositories/" + url.PathEscape(azureDevOpsPoller.Repository) + "/pullrequests"
+}
+
+func (azureDevOpsPoller AzureDevOpsPoller) newRequest(ctx context.Context, requestUrl string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(azureDevOpsPoller.AccessToken) > 0 {
+		// personal access tokens are sent as password with an empty username
+		req.SetBasicAuth("", strings.TrimSuffix(azureDevOpsPoller.AccessToken, "\n"))
+	}
+	return req, nil
+}
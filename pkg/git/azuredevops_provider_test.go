@@ -0,0 +1,114 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAzureDevOpsPollerPollSkip(t *testing.T) {
+	// the api does not report further pages, the listing ends with the first page which is not full
+	const total = 200
+	var skips []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jquad/pipelines/_apis/git/repositories/microservice/pullrequests" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("searchCriteria.status") != "active" || query.Get("searchCriteria.targetRefName") != "refs/heads/main" || query.Get("api-version") != "7.0" || query.Get("$top") != "100" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		if _, password, ok := r.BasicAuth(); !ok || password != "secret" {
+			t.Errorf("expected the access token as password, got %q", password)
+		}
+		skips = append(skips, query.Get("$skip"))
+		skip, _ := strconv.Atoi(query.Get("$skip"))
+		last := skip + 100
+		if last > total {
+			last = total
+		}
+		body := `{"value": [`
+		for i := skip; i < last; i++ {
+			if i > skip {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"pullRequestId": %d, "status": "active", "sourceRefName": "refs/heads/feature-%d", "targetRefName": "refs/heads/main", "lastMergeSourceCommit": {"commitId": "%040d"}, "labels": [{"name": "ci/run"}], "isDraft": %t}`, i+1, i+1, i+1, i == 0)
+		}
+		body += fmt.Sprintf(`], "count": %d}`, last-skip)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	poller := NewAzureDevOpsPoller(server.URL, "secret\n", TransportOptions{}, "jquad", "pipelines", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last page is full, the empty page after it ends the listing
+	if strings.Join(skips, ",") != "0,100,200" {
+		t.Errorf("expected the pages to be skipped by 100 until an empty page, got %v", skips)
+	}
+	if branches.GetSize() != total {
+		t.Fatalf("expected the pull requests of all pages, got %d", branches.GetSize())
+	}
+	first := branches.Branches[0]
+	if first.ID != "1" || first.Name != "feature-1" || first.Commit != fmt.Sprintf("%040d", 1) || first.TargetBranch != "main" || !first.Draft || len(first.Labels) != 1 || first.Labels[0] != "ci/run" || first.Details == "" {
+		t.Errorf("unexpected branch %+v", first)
+	}
+}
+
+func TestAzureDevOpsPollerPollError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	poller := NewAzureDevOpsPoller(server.URL, "wrong", TransportOptions{}, "jquad", "pipelines", "microservice")
+	_, _, err := poller.Poll(context.Background(), "main", "")
+	if statusCode, ok := StatusCode(err); !ok || statusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestAzureDevOpsPollerGetState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jquad/pipelines/_apis/git/repositories/microservice/pullrequests/1":
+			w.Write([]byte(`{"pullRequestId": 1, "status": "completed", "lastMergeCommit": {"commitId": "4444444444444444444444444444444444444444"}}`))
+		case "/jquad/pipelines/_apis/git/repositories/microservice/pullrequests/2":
+			w.Write([]byte(`{"pullRequestId": 2, "status": "abandoned"}`))
+		case "/jquad/pipelines/_apis/git/repositories/microservice/pullrequests/3":
+			w.Write([]byte(`{"pullRequestId": 3, "status": "notSet"}`))
+		case "/jquad/pipelines/_apis/git/repositories/microservice/pullrequests/4":
+			w.Write([]byte(`{"pullRequestId": 4, "status": "active"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	poller := NewAzureDevOpsPoller(server.URL, "", TransportOptions{}, "jquad", "pipelines", "microservice")
+	for id, expected := range map[string][2]string{
+		"1": {"merged", "4444444444444444444444444444444444444444"},
+		"2": {"declined", ""},
+		"3": {"closed", ""},
+		"4": {"open", ""},
+	} {
+		state, mergeCommit, err := poller.GetState(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != expected[0] || mergeCommit != expected[1] {
+			t.Errorf("%s: expected %s with merge commit %q, got %s with %q", id, expected[0], expected[1], state, mergeCommit)
+		}
+	}
+
+	if _, _, err := poller.GetState(context.Background(), "5"); err == nil {
+		t.Error("expected an error for an unknown pull request")
+	}
+}
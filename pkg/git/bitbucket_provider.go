@@ -2,11 +2,12 @@ package v1alpha1
 
 import (
 	"context"
-	"crypto/tls"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,66 +15,124 @@ import (
 	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
 )
 
+const (
+	bitbucketPageSize = 100
+	// prefix of the etags, which are computed from the listed pull requests, as most Bitbucket Server versions do
+	// not send an etag
+	bitbucketDigestPrefix = "sha256:"
+	// the action of the activity, which reopened a declined pull request, is not defined by the client
+	bitbucketActionReopened bitbucketClient.Action = "REOPENED"
+)
+
 type BitbucketPoller struct {
-	Endpoint           string
-	AccessToken        string
-	InsecureSkipVerify bool
-	Project            string
-	Repository         string
+	Endpoint    string
+	AccessToken string
+	Transport   TransportOptions
+	Project     string
+	Repository  string
 }
 
-func NewBitbucketPoller(endpoint string, accessToken string, insecureSkipVerify bool, project string, repository string) *BitbucketPoller {
+func NewBitbucketPoller(endpoint string, accessToken string, transportOptions TransportOptions, project string, repository string) *BitbucketPoller {
 	return &BitbucketPoller{
-		Endpoint:           endpoint,
-		AccessToken:        accessToken,
-		InsecureSkipVerify: insecureSkipVerify,
-		Project:            project,
-		Repository:         repository,
+		Endpoint:    endpoint,
+		AccessToken: accessToken,
+		Transport:   transportOptions,
+		Project:     project,
+		Repository:  repository,
 	}
 }
 
-func (bitbucketPoller BitbucketPoller) Poll(branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
-	accessToken := strings.TrimSuffix(bitbucketPoller.AccessToken, "\n")
-	ctx, cancel := context.WithTimeout(context.Background(), 6000*time.Millisecond)
-	if len(bitbucketPoller.AccessToken) > 0 {
-		ctx = context.WithValue(ctx, bitbucketClient.ContextAccessToken, accessToken)
+// Poll returns no pull requests together with the given etag, if the pull requests did not change since the poll which
+// returned the etag. The etag is sent by the server, or is a digest of the listed pull requests otherwise.
+func (bitbucketPoller BitbucketPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	client, err := bitbucketPoller.newClient(ctx)
+	if err != nil {
+		return pullrequestv1alpha1.Branches{}, "", err
 	}
-	defer cancel()
-	if bitbucketPoller.InsecureSkipVerify {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	// only the first page is a conditional request, a digest is never sent to the server
+	firstPageClient := client
+	if !strings.HasPrefix(etag, bitbucketDigestPrefix) {
+		firstPageClient, err = bitbucketPoller.newClient(withETag(ctx, etag))
+		if err != nil {
+			return pullrequestv1alpha1.Branches{}, "", err
+		}
 	}
-	bitbucketConfig := bitbucketClient.NewConfiguration(bitbucketPoller.Endpoint)
-	client := bitbucketClient.NewAPIClient(
-		ctx,
-		bitbucketConfig,
-	)
 
 	opts := map[string]interface{}{
 		"direction": "INCOMING",
-		"at":        branch,
+		"limit":     bitbucketPageSize,
+	}
+	// without a branch, the pull requests of all target branches are listed
+	if len(branch) > 0 {
+		opts["at"] = branch
 	}
 
 	var branches pullrequestv1alpha1.Branches
 
-	response, err := client.DefaultApi.GetPullRequestsPage(bitbucketPoller.Project, bitbucketPoller.Repository, opts)
-	fmt.Println(response)
-	//response, err := client.DefaultApi.GetSSHKeys(username)
-	if err != nil {
-		//fmt.Printf("%s\n", err.Error())
-		return branches, "", err
+	var prList []bitbucketClient.PullRequest
+	var drafts []bool
+	eTag := ""
+	for pageClient := firstPageClient; ; pageClient = client {
+		response, err := pageClient.DefaultApi.GetPullRequestsPage(bitbucketPoller.Project, bitbucketPoller.Repository, opts)
+		if response != nil && response.Response != nil && response.StatusCode == http.StatusNotModified {
+			recordListing(pullrequestv1alpha1.BITBUCKET_PROVIDER_NAME, true)
+			branches.NotModified = true
+			return branches, etag, nil
+		}
+		if err != nil {
+			return branches, "", bitbucketError(response, err)
+		}
+		// the etag of the first page identifies the listing
+		if _, paged := opts["start"]; !paged {
+			eTag = response.Header.Get("ETag")
+		}
+
+		prPage, err := bitbucketClient.GetPullRequestsResponse(response)
+		if err != nil {
+			return branches, "", err
+		}
+		prList = append(prList, prPage...)
+		drafts = append(drafts, bitbucketDrafts(response, len(prPage))...)
+
+		hasNextPage, nextPageStart := bitbucketClient.HasNextPage(response)
+		if !hasNextPage {
+			break
+		}
+		opts["start"] = nextPageStart
+		// the etag of the first page does not cover a change on the following pages
+		eTag = ""
 	}
 
-	prList, err := bitbucketClient.GetPullRequestsResponse(response)
-	if err != nil {
-		//fmt.Println(err)
-		return branches, "", err
+	if len(eTag) == 0 {
+		list, err := json.Marshal(struct {
+			PullRequests []bitbucketClient.PullRequest
+			Drafts       []bool
+		}{prList, drafts})
+		if err != nil {
+			return branches, "", err
+		}
+		digest := sha256.Sum256(list)
+		eTag = bitbucketDigestPrefix + hex.EncodeToString(digest[:])
+	}
+	// a listing with the same digest was not modified, although the server answered with 200
+	recordListing(pullrequestv1alpha1.BITBUCKET_PROVIDER_NAME, eTag == etag)
+	if eTag == etag {
+		branches.NotModified = true
+		return branches, etag, nil
 	}
 
 	sourceBranches := make([]pullrequestv1alpha1.Branch, len(prList))
 	for i := 0; i < len(prList); i++ {
 		var tempBranch pullrequestv1alpha1.Branch
+		tempBranch.ID = strconv.Itoa(prList[i].ID)
 		tempBranch.Name = prList[i].FromRef.DisplayID
 		tempBranch.Commit = prList[i].FromRef.LatestCommit
+		tempBranch.TargetBranch = prList[i].ToRef.DisplayID
+		tempBranch.Draft = drafts[i]
+		tempBranch.Fork = prList[i].FromRef.Repository.ID != prList[i].ToRef.Repository.ID
+		if prList[i].Author != nil {
+			tempBranch.Author = prList[i].Author.User.Slug
+		}
 		pr, err := json.Marshal(prList[i])
 		if err != nil {
 			//fmt.Println(err)
@@ -85,6 +144,150 @@ func (bitbucketPoller BitbucketPoller) Poll(branch string, etag string) (pullreq
 
 	branches.Branches = sourceBranches
 
-	return branches, "", nil
+	return branches, eTag, nil
+
+}
+
+// bitbucketDrafts reads the draft flags of the pull requests of a page, which are reported by Bitbucket Data Center 8.18
+// and later and are not part of the client's model
+func bitbucketDrafts(response *bitbucketClient.APIResponse, count int) []bool {
+	drafts := make([]bool, count)
+	values, _ := response.Values["values"].([]interface{})
+	for i := 0; i < len(values) && i < count; i++ {
+		if pr, ok := values[i].(map[string]interface{}); ok {
+			drafts[i], _ = pr["draft"].(bool)
+		}
+	}
+	return drafts
+}
+
+func (bitbucketPoller BitbucketPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	client, err := bitbucketPoller.newClient(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	pullRequestID, err := strconv.Atoi(id)
+	if err != nil {
+		return "", "", err
+	}
+	response, err := client.DefaultApi.GetPullRequest(bitbucketPoller.Project, bitbucketPoller.Repository, pullRequestID)
+	if err != nil {
+		return "", "", bitbucketError(response, err)
+	}
+	pr, err := bitbucketClient.GetPullRequestResponse(response)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch pr.State {
+	case "OPEN":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	case "MERGED":
+		// the merge commit is only reported by Bitbucket Server 7 and later and is not part of the client's model
+		mergeCommit := ""
+		if properties, ok := response.Values["properties"].(map[string]interface{}); ok {
+			if commit, ok := properties["mergeCommit"].(map[string]interface{}); ok {
+				mergeCommit, _ = commit["id"].(string)
+			}
+		}
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, mergeCommit, nil
+	case "DECLINED":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_DECLINED, "", nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+func (bitbucketPoller BitbucketPoller) ListComments(ctx context.Context, id string) ([]Comment, error) {
+	activities, err := bitbucketPoller.listActivities(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, activity := range activities {
+		if activity.Action == bitbucketClient.ActionCommented && activity.CommentAction == "ADDED" {
+			comments = appendBitbucketComments(comments, activity.Comment)
+		}
+	}
+	return comments, nil
+}
+
+// GetCommitTime returns the time the commit was pushed to the pull request, or the time the pull request was opened
+// or reopened with the commit
+func (bitbucketPoller BitbucketPoller) GetCommitTime(ctx context.Context, id string, commit string) (time.Time, error) {
+	activities, err := bitbucketPoller.listActivities(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// the activities are listed newest first, a rescope of the target branch does not change the source commit
+	for _, activity := range activities {
+		if activity.Action == bitbucketClient.ActionRescoped && activity.FromHash == commit && activity.PreviousFromHash != commit {
+			return time.UnixMilli(int64(activity.CreatedDate)), nil
+		}
+		// the commits pushed while the pull request was declined are not reported as rescope
+		if activity.Action == bitbucketClient.ActionOpened || activity.Action == bitbucketActionReopened {
+			return time.UnixMilli(int64(activity.CreatedDate)), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("the push of the commit %s to the pull request %s was not found", commit, id)
+}
+
+// listActivities returns all activities of the pull request, newest first
+func (bitbucketPoller BitbucketPoller) listActivities(ctx context.Context, id string) ([]bitbucketClient.Activity, error) {
+	client, err := bitbucketPoller.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pullRequestID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, err
+	}
 
+	var activities []bitbucketClient.Activity
+	opts := map[string]interface{}{
+		"limit": bitbucketPageSize,
+	}
+	for {
+		response, err := client.DefaultApi.GetActivities(bitbucketPoller.Project, bitbucketPoller.Repository, pullRequestID, opts)
+		if err != nil {
+			return nil, bitbucketError(response, err)
+		}
+		page, err := bitbucketClient.GetActivitiesResponse(response)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, page.Values...)
+		if page.IsLastPage {
+			return activities, nil
+		}
+		opts["start"] = page.NextPageStart
+	}
+}
+
+// appendBitbucketComments appends the comment together with the replies to it
+func appendBitbucketComments(comments []Comment, comment bitbucketClient.ActivityComment) []Comment {
+	comments = append(comments, Comment{Author: comment.Author.Slug, Body: comment.Text, Created: time.UnixMilli(comment.CreatedDate)})
+	for _, reply := range comment.Comments {
+		comments = appendBitbucketComments(comments, reply)
+	}
+	return comments
+}
+
+func (bitbucketPoller BitbucketPoller) newClient(ctx context.Context) (*bitbucketClient.APIClient, error) {
+	accessToken := strings.TrimSuffix(bitbucketPoller.AccessToken, "\n")
+	if len(bitbucketPoller.AccessToken) > 0 {
+		ctx = context.WithValue(ctx, bitbucketClient.ContextAccessToken, accessToken)
+	}
+	httpClient, err := newHTTPClient(bitbucketPoller.Transport)
+	if err != nil {
+		return nil, err
+	}
+	bitbucketConfig := bitbucketClient.NewConfiguration(bitbucketPoller.Endpoint)
+	// the etag of the context is sent with the requests
+	httpClient.Transport = &transportHeaders{transport: httpClient.Transport}
+	bitbucketConfig.HTTPClient = httpClient
+	return bitbucketClient.NewAPIClient(
+		ctx,
+		bitbucketConfig,
+	), nil
 }
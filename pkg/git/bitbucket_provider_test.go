@@ -0,0 +1,190 @@
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const bitbucketPullRequestsResponse = `{"values": [
+	{"id": 1, "fromRef": {"displayId": "feature-a", "latestCommit": "1111111111111111111111111111111111111111", "repository": {"id": 7}}, "toRef": {"displayId": "main", "repository": {"id": 7}}},
+	{"id": 2, "fromRef": {"displayId": "feature-b", "latestCommit": "2222222222222222222222222222222222222222", "repository": {"id": 8}}, "toRef": {"displayId": "main", "repository": {"id": 7}}, "author": {"user": {"slug": "contributor"}}, "draft": true}
+], "isLastPage": true}`
+
+func TestBitbucketPollerDigest(t *testing.T) {
+	response := bitbucketPullRequestsResponse
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("If-None-Match")) > 0 {
+			t.Errorf("expected the digest not to be sent, got %s", r.Header.Get("If-None-Match"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice")
+	branches, eTag, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || !strings.HasPrefix(eTag, bitbucketDigestPrefix) {
+		t.Fatalf("expected 2 branches with a digest, got %d branches and etag %q", branches.GetSize(), eTag)
+	}
+	if branches.Branches[0].Draft || !branches.Branches[1].Draft {
+		t.Errorf("expected the second pull request to be a draft, got %+v", branches.Branches)
+	}
+	if branches.Branches[0].Fork || !branches.Branches[1].Fork || branches.Branches[1].Author != "contributor" {
+		t.Errorf("expected the second pull request to be opened from a fork, got %+v", branches.Branches)
+	}
+
+	notModified := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.BITBUCKET_PROVIDER_NAME, "304"))
+	branches, unchangedETag, err := poller.Poll(context.Background(), "main", eTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !branches.NotModified || branches.GetSize() != 0 || unchangedETag != eTag {
+		t.Errorf("expected no branches for an unchanged listing, got %d branches and etag %q", branches.GetSize(), unchangedETag)
+	}
+	// the digest match is counted as not modified listing, although the server answered with 200
+	if delta := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.BITBUCKET_PROVIDER_NAME, "304")) - notModified; delta != 1 {
+		t.Errorf("expected 1 listing with 304, got %v", delta)
+	}
+
+	response = strings.Replace(bitbucketPullRequestsResponse, "2222222222222222222222222222222222222222", "3333333333333333333333333333333333333333", 1)
+	branches, changedETag, err := poller.Poll(context.Background(), "main", eTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.NotModified || branches.GetSize() != 2 || changedETag == eTag {
+		t.Errorf("expected the changed listing with a new digest, got %d branches and etag %q", branches.GetSize(), changedETag)
+	}
+}
+
+func TestBitbucketPollerNotModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"listing-1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"listing-1"`)
+		w.Write([]byte(bitbucketPullRequestsResponse))
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice")
+	branches, eTag, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || eTag != `"listing-1"` {
+		t.Fatalf("expected 2 branches with the etag of the server, got %d branches and etag %q", branches.GetSize(), eTag)
+	}
+
+	branches, eTag, err = poller.Poll(context.Background(), "main", eTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !branches.NotModified || branches.GetSize() != 0 || eTag != `"listing-1"` {
+		t.Errorf("expected no branches for a not modified listing, got %d branches and etag %q", branches.GetSize(), eTag)
+	}
+}
+
+func TestBitbucketPollerNotModifiedPages(t *testing.T) {
+	secondPage := `{"values": [{"id": 2, "fromRef": {"displayId": "feature-b", "latestCommit": "2222222222222222222222222222222222222222"}, "toRef": {"displayId": "main"}}], "isLastPage": true}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"page-1"`)
+		if r.URL.Query().Get("start") == "1" {
+			if len(r.Header.Get("If-None-Match")) > 0 {
+				t.Errorf("expected only the first page to be conditional, got %s", r.Header.Get("If-None-Match"))
+			}
+			w.Write([]byte(secondPage))
+			return
+		}
+		w.Write([]byte(`{"values": [{"id": 1, "fromRef": {"displayId": "feature-a", "latestCommit": "1111111111111111111111111111111111111111"}, "toRef": {"displayId": "main"}}], "isLastPage": false, "nextPageStart": 1}`))
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice")
+	branches, eTag, err := poller.Poll(context.Background(), "main", `"page-1"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || !strings.HasPrefix(eTag, bitbucketDigestPrefix) {
+		t.Fatalf("expected 2 branches with a digest, got %d branches and etag %q", branches.GetSize(), eTag)
+	}
+
+	// a change on the second page is not hidden by the unchanged first page
+	secondPage = strings.Replace(secondPage, "2222222222222222222222222222222222222222", "3333333333333333333333333333333333333333", 1)
+	branches, _, err = poller.Poll(context.Background(), "main", eTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.NotModified || branches.GetSize() != 2 || branches.Branches[1].Commit != "3333333333333333333333333333333333333333" {
+		t.Errorf("expected the changed second page, got %+v", branches)
+	}
+}
+
+func TestBitbucketPollerListComments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/jquad/repos/microservice/pull-requests/2/activities" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"values": [
+			{"action": "COMMENTED", "commentAction": "ADDED", "comment": {"text": "Please review", "author": {"slug": "contributor"}, "createdDate": 1667304000000,
+				"comments": [{"text": "/ok-to-test", "author": {"slug": "maintainer"}, "createdDate": 1667307600000}]}},
+			{"action": "COMMENTED", "commentAction": "DELETED", "comment": {"text": "/ok-to-test", "author": {"slug": "stranger"}, "createdDate": 1667307600000}},
+			{"action": "OPENED"}
+		], "isLastPage": true}`))
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice")
+	comments, err := poller.ListComments(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[1].Author != "maintainer" || comments[1].Body != "/ok-to-test" || !comments[1].Created.Equal(time.UnixMilli(1667307600000)) {
+		t.Errorf("expected the comment with its reply, got %+v", comments)
+	}
+}
+
+func TestBitbucketPollerGetCommitTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"values": [
+			{"action": "RESCOPED", "createdDate": 1667311200000, "fromHash": "3333333333333333333333333333333333333333", "previousFromHash": "3333333333333333333333333333333333333333"},
+			{"action": "RESCOPED", "createdDate": 1667307600000, "fromHash": "3333333333333333333333333333333333333333", "previousFromHash": "1111111111111111111111111111111111111111"},
+			{"action": "REOPENED", "createdDate": 1667305800000},
+			{"action": "DECLINED", "createdDate": 1667304900000},
+			{"action": "OPENED", "createdDate": 1667304000000}
+		], "isLastPage": true}`))
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice")
+	// the rescope of the target branch does not push the commit
+	pushed, err := poller.GetCommitTime(context.Background(), "2", "3333333333333333333333333333333333333333")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed.Equal(time.UnixMilli(1667307600000)) {
+		t.Errorf("expected the time of the push, got %s", pushed)
+	}
+	// the commit may have been pushed while the pull request was declined
+	reopened, err := poller.GetCommitTime(context.Background(), "2", "1111111111111111111111111111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.Equal(time.UnixMilli(1667305800000)) {
+		t.Errorf("expected the time the pull request was reopened, got %s", reopened)
+	}
+}
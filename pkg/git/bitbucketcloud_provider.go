@@ -0,0 +1,171 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+const (
+	bitbucketCloudDefaultEndpoint = "https://api.bitbucket.org/2.0"
+	bitbucketCloudPageSize        = 50
+)
+
+type BitbucketCloudPoller struct {
+	Endpoint    string
+	Username    string
+	AccessToken string
+	Transport   TransportOptions
+	Workspace   string
+	Repository  string
+}
+
+type bitbucketCloudPullRequestPage struct {
+	Values []json.RawMessage `json:"values"`
+	Next   string            `json:"next"`
+}
+
+type bitbucketCloudPullRequest struct {
+	Id          int    `json:"id"`
+	State       string `json:"state"`
+	MergeCommit struct {
+		Hash string `json:"hash"`
+	} `json:"merge_commit"`
+	Source struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Commit struct {
+			Hash string `json:"hash"`
+		} `json:"commit"`
+	} `json:"source"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	Draft bool `json:"draft"`
+}
+
+// NewBitbucketCloudPoller creates a poller for the Bitbucket Cloud 2.0 API. If a username is given, the access token
+// is used as app password, otherwise it is sent as bearer token (repository, project or workspace access token).
+func NewBitbucketCloudPoller(endpoint string, username string, accessToken string, transportOptions TransportOptions, workspace string, repository string) *BitbucketCloudPoller {
+	if len(endpoint) == 0 {
+		endpoint = bitbucketCloudDefaultEndpoint
+	}
+	return &BitbucketCloudPoller{
+		Endpoint:    endpoint,
+		Username:    username,
+		AccessToken: accessToken,
+		Transport:   transportOptions,
+		Workspace:   workspace,
+		Repository:  repository,
+	}
+}
+
+func (bitbucketCloudPoller BitbucketCloudPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	httpClient, err := newHTTPClient(bitbucketCloudPoller.Transport)
+	if err != nil {
+		return pullrequestv1alpha1.Branches{}, "", err
+	}
+
+	var branches pullrequestv1alpha1.Branches
+
+	firstPage, err := url.Parse(bitbucketCloudPoller.pullRequestsUrl())
+	if err != nil {
+		return branches, "", err
+	}
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	// without a branch, the pull requests of all target branches are listed
+	if len(branch) > 0 {
+		query.Set("q", "destination.branch.name=\""+trimBranchRef(branch)+"\"")
+	}
+	query.Set("pagelen", strconv.Itoa(bitbucketCloudPageSize))
+	firstPage.RawQuery = query.Encode()
+
+	var sourceBranches []pullrequestv1alpha1.Branch
+	// the response of each page contains the complete url of the next page
+	for pageUrl := firstPage.String(); pageUrl != ""; {
+		req, err := bitbucketCloudPoller.newRequest(ctx, pageUrl)
+		if err != nil {
+			return branches, "", err
+		}
+
+		var prPage bitbucketCloudPullRequestPage
+		err = getJSON(httpClient, req, &prPage)
+		if err != nil {
+			return branches, "", err
+		}
+
+		for i := 0; i < len(prPage.Values); i++ {
+			var pr bitbucketCloudPullRequest
+			if err := json.Unmarshal(prPage.Values[i], &pr); err != nil {
+				return branches, "", err
+			}
+			var tempBranch pullrequestv1alpha1.Branch
+			tempBranch.ID = strconv.Itoa(pr.Id)
+			tempBranch.Name = pr.Source.Branch.Name
+			tempBranch.Commit = pr.Source.Commit.Hash
+			tempBranch.TargetBranch = pr.Destination.Branch.Name
+			tempBranch.Draft = pr.Draft
+			tempBranch.Details = string(prPage.Values[i])
+			sourceBranches = append(sourceBranches, tempBranch)
+		}
+
+		pageUrl = prPage.Next
+	}
+
+	branches.Branches = sourceBranches
+
+	return branches, "", nil
+}
+
+func (bitbucketCloudPoller BitbucketCloudPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	httpClient, err := newHTTPClient(bitbucketCloudPoller.Transport)
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := bitbucketCloudPoller.newRequest(ctx, bitbucketCloudPoller.pullRequestsUrl()+"/"+url.PathEscape(id))
+	if err != nil {
+		return "", "", err
+	}
+	var pr bitbucketCloudPullRequest
+	if err := getJSON(httpClient, req, &pr); err != nil {
+		return "", "", err
+	}
+
+	switch pr.State {
+	case "OPEN":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	case "MERGED":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, pr.MergeCommit.Hash, nil
+	case "DECLINED":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_DECLINED, "", nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+func (bitbucketCloudPoller BitbucketCloudPoller) pullRequestsUrl() string {
+	return strings.TrimSuffix(bitbucketCloudPoller.Endpoint, "/") + "/repositories/" + url.PathEscape(bitbucketCloudPoller.Workspace) + "/" + url.PathEscape(bitbucketCloudPoller.Repository) + "/pullrequests"
+}
+
+func (bitbucketCloudPoller BitbucketCloudPoller) newRequest(ctx context.Context, requestUrl string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := strings.TrimSuffix(bitbucketCloudPoller.AccessToken, "\n")
+	if len(bitbucketCloudPoller.Username) > 0 {
+		req.SetBasicAuth(strings.TrimSuffix(bitbucketCloudPoller.Username, "\n"), accessToken)
+	} else if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return req, nil
+}
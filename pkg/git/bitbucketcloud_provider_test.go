@@ -0,0 +1,107 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBitbucketCloudPollerPollNextUrl(t *testing.T) {
+	var server *httptest.Server
+	var requests []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "jquad" || password != "secret" {
+			t.Errorf("unexpected basic auth %s/%s", username, password)
+		}
+		requests = append(requests, r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/2.0/repositories/jquad/microservice/pullrequests":
+			if r.URL.Query().Get("state") != "OPEN" || r.URL.Query().Get("q") != `destination.branch.name="main"` || r.URL.Query().Get("pagelen") != "50" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			// the next page is given by an opaque url, which is followed as is
+			fmt.Fprintf(w, `{"values": [{"id": 1, "state": "OPEN", "source": {"branch": {"name": "feature-1"}, "commit": {"hash": "111111111111"}}, "destination": {"branch": {"name": "main"}}, "draft": true}], "next": "%s/2.0/repositories/jquad/microservice/pullrequests/page?cursor=a1b2"}`, server.URL)
+		case "/2.0/repositories/jquad/microservice/pullrequests/page":
+			if r.URL.RawQuery != "cursor=a1b2" {
+				t.Errorf("expected the query of the next url only, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"values": [{"id": 2, "state": "OPEN", "source": {"branch": {"name": "feature-2"}, "commit": {"hash": "222222222222"}}, "destination": {"branch": {"name": "main"}}}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketCloudPoller(server.URL+"/2.0", "jquad\n", "secret\n", TransportOptions{}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "refs/heads/main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("expected the listing to end without a next url, got the requests %v", requests)
+	}
+	if branches.GetSize() != 2 {
+		t.Fatalf("expected the pull requests of both pages, got %d", branches.GetSize())
+	}
+	first := branches.Branches[0]
+	if first.ID != "1" || first.Name != "feature-1" || first.Commit != "111111111111" || first.TargetBranch != "main" || !first.Draft || first.Details == "" {
+		t.Errorf("unexpected branch %+v", first)
+	}
+}
+
+func TestBitbucketCloudPollerPollError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer wrong" {
+			t.Errorf("expected a bearer token without username, got %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketCloudPoller(server.URL+"/2.0", "", "wrong", TransportOptions{}, "jquad", "microservice")
+	_, _, err := poller.Poll(context.Background(), "main", "")
+	if statusCode, ok := StatusCode(err); !ok || statusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestBitbucketCloudPollerGetState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2.0/repositories/jquad/microservice/pullrequests/1":
+			w.Write([]byte(`{"id": 1, "state": "MERGED", "merge_commit": {"hash": "444444444444"}}`))
+		case "/2.0/repositories/jquad/microservice/pullrequests/2":
+			w.Write([]byte(`{"id": 2, "state": "DECLINED", "merge_commit": null}`))
+		case "/2.0/repositories/jquad/microservice/pullrequests/3":
+			w.Write([]byte(`{"id": 3, "state": "SUPERSEDED"}`))
+		case "/2.0/repositories/jquad/microservice/pullrequests/4":
+			w.Write([]byte(`{"id": 4, "state": "OPEN"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	poller := NewBitbucketCloudPoller(server.URL+"/2.0", "", "", TransportOptions{}, "jquad", "microservice")
+	for id, expected := range map[string][2]string{
+		"1": {"merged", "444444444444"},
+		"2": {"declined", ""},
+		"3": {"closed", ""},
+		"4": {"open", ""},
+	} {
+		state, mergeCommit, err := poller.GetState(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != expected[0] || mergeCommit != expected[1] {
+			t.Errorf("%s: expected %s with merge commit %q, got %s with %q", id, expected[0], expected[1], state, mergeCommit)
+		}
+	}
+
+	if _, _, err := poller.GetState(context.Background(), "5"); err == nil {
+		t.Error("expected an error for an unknown pull request")
+	}
+}
@@ -0,0 +1,81 @@
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type cachedClient struct {
+	client   interface{}
+	lastUsed time.Time
+	// repositories, which currently use the client
+	users map[string]bool
+}
+
+// clients of the git providers, which hold the credentials, e.g. the github client, are shared between
+// reconciliations and objects. They are cached by the endpoint, a hash of the credentials and the transport settings,
+// so that a changed secret never reuses the client of the previous credentials. The other providers send the
+// credentials with each request and only share the cached transport.
+var clients = struct {
+	sync.Mutex
+	cache map[string]*cachedClient
+	// key of the client, which was last used by a repository
+	users map[string]string
+}{cache: map[string]*cachedClient{}, users: map[string]string{}}
+
+// getClient returns the cached client, or creates a new one with the cached transport of the options. The client of
+// previous credentials of the repository is removed, as soon as no other repository uses it, e.g. after the rotation
+// of a github app installation token.
+func getClient(endpoint string, repository string, credentials string, transportOptions TransportOptions, newClient func(transport http.RoundTripper) (interface{}, error)) (interface{}, error) {
+	transportHash, err := transportOptions.hash()
+	if err != nil {
+		return nil, err
+	}
+	credentialsHash := sha256.Sum256([]byte(credentials))
+	key := endpoint + "/" + hex.EncodeToString(credentialsHash[:]) + "/" + transportHash + "/" + transportOptions.timeout().String()
+
+	clients.Lock()
+	defer clients.Unlock()
+
+	// clients of deleted objects are removed once they are no longer used
+	now := time.Now()
+	for cachedKey, cached := range clients.cache {
+		if now.Sub(cached.lastUsed) > transportIdleTimeout {
+			for user := range cached.users {
+				delete(clients.users, user)
+			}
+			delete(clients.cache, cachedKey)
+		}
+	}
+
+	user := endpoint + "/" + repository
+	if previousKey, ok := clients.users[user]; ok && previousKey != key {
+		if previous, ok := clients.cache[previousKey]; ok {
+			delete(previous.users, user)
+			if len(previous.users) == 0 {
+				delete(clients.cache, previousKey)
+			}
+		}
+	}
+	clients.users[user] = key
+
+	if cached, ok := clients.cache[key]; ok {
+		cached.lastUsed = now
+		cached.users[user] = true
+		return cached.client, nil
+	}
+
+	transport, err := getTransport(transportOptions)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient(transport)
+	if err != nil {
+		return nil, err
+	}
+	clients.cache[key] = &cachedClient{client: client, lastUsed: now, users: map[string]bool{user: true}}
+	return client, nil
+}
@@ -0,0 +1,69 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGithubPollerSharesClient(t *testing.T) {
+	first, err := NewGithubPoller("https://ghe.jquad.rocks", "token", TransportOptions{}, "jquad", "microservice").newClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewGithubPoller("https://ghe.jquad.rocks", "token", TransportOptions{}, "jquad", "other").newClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected pollers with the same endpoint, credentials and transport settings to share the client")
+	}
+
+	for name, poller := range map[string]*GithubPoller{
+		"changed secret":   NewGithubPoller("https://ghe.jquad.rocks", "rotated", TransportOptions{}, "jquad", "microservice"),
+		"insecure":         NewGithubPoller("https://ghe.jquad.rocks", "token", TransportOptions{TLSOptions: TLSOptions{InsecureSkipVerify: true}}, "jquad", "microservice"),
+		"another endpoint": NewGithubPoller("https://github.jquad.rocks", "token", TransportOptions{}, "jquad", "microservice"),
+	} {
+		client, err := poller.newClient(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client == first {
+			t.Errorf("%s: expected a separate client", name)
+		}
+	}
+}
+
+func TestGithubPollerRemovesClientOfRotatedCredentials(t *testing.T) {
+	cachedClients := func() int {
+		clients.Lock()
+		defer clients.Unlock()
+		return len(clients.cache)
+	}
+	first, err := NewGithubPoller("https://rotation.jquad.rocks", "token", TransportOptions{}, "jquad", "microservice").newClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewGithubPoller("https://rotation.jquad.rocks", "token", TransportOptions{}, "jquad", "other").newClient(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	before := cachedClients()
+
+	// the client of the previous token is still used by the other repository
+	rotated, err := NewGithubPoller("https://rotation.jquad.rocks", "rotated", TransportOptions{}, "jquad", "microservice").newClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated == first {
+		t.Error("expected a separate client for the rotated token")
+	}
+	if cachedClients() != before+1 {
+		t.Errorf("expected the client of the previous token to be kept, got %d cached clients instead of %d", cachedClients(), before+1)
+	}
+
+	if _, err := NewGithubPoller("https://rotation.jquad.rocks", "rotated", TransportOptions{}, "jquad", "other").newClient(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cachedClients() != before {
+		t.Errorf("expected the client of the previous token to be removed, got %d cached clients instead of %d", cachedClients(), before)
+	}
+}
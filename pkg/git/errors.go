@@ -0,0 +1,72 @@
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	bitbucketClient "github.com/gfleury/go-bitbucket-v1"
+	githubClient "github.com/google/go-github/v42/github"
+)
+
+// StatusError is returned if the git provider answered a request with an unsuccessful status
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %s", e.Method, e.URL, e.Status)
+}
+
+// StatusCode returns the status of the unsuccessful response, which caused the error of a poller
+func StatusCode(err error) (int, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode, true
+	}
+	var githubErr *githubClient.ErrorResponse
+	if errors.As(err, &githubErr) && githubErr.Response != nil {
+		return githubErr.Response.StatusCode, true
+	}
+	return 0, false
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+}
+
+// checkResponse returns an error for an unsuccessful response, a RateLimitError if the request was rate limited
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	err := newStatusError(resp)
+	rateLimit, _ := parseRateLimit(resp.Header, time.Now())
+	// GitHub and Gitea reject requests with 403 Forbidden if the quota is exhausted
+	exhausted := resp.Header.Get("X-RateLimit-Remaining") == "0" || len(resp.Header.Get("Retry-After")) > 0
+	if resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusForbidden && exhausted) {
+		return &RateLimitError{RateLimit: rateLimit, Err: err}
+	}
+	return err
+}
+
+// bitbucketError adds the status of the response to the error of the bitbucket client, which only reports it in the
+// message
+func bitbucketError(response *bitbucketClient.APIResponse, err error) error {
+	if response == nil || response.Response == nil || response.Response.Request == nil {
+		return err
+	}
+	if checked := checkResponse(response.Response); checked != nil {
+		return fmt.Errorf("%w: %v", checked, err)
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPollerStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	for name, poller := range map[string]PullrequestPoller{
+		"bitbucket": NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice"),
+		"github":    NewGithubPoller(server.URL+"/", "secret", TransportOptions{}, "jquad", "microservice"),
+		"gitea":     NewGiteaPoller(server.URL, "secret", TransportOptions{}, "jquad", "microservice"),
+	} {
+		_, _, err := poller.Poll(context.Background(), "main", "")
+		if statusCode, ok := StatusCode(err); !ok || statusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected the status of the response, got %d from %v", name, statusCode, err)
+		}
+	}
+}
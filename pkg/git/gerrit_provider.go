@@ -0,0 +1,193 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+const (
+	gerritPageSize = 100
+	// Gerrit prefixes every JSON response to prevent XSSI
+	gerritMagicPrefix = ")]}'"
+)
+
+type GerritPoller struct {
+	Endpoint    string
+	Username    string
+	AccessToken string
+	Transport   TransportOptions
+	Project     string
+}
+
+type gerritChange struct {
+	Number          int    `json:"_number"`
+	Status          string `json:"status"`
+	Branch          string `json:"branch"`
+	CurrentRevision string `json:"current_revision"`
+	Revisions       map[string]struct {
+		Ref string `json:"ref"`
+	} `json:"revisions"`
+	// hashtags are the labels of gerrit changes
+	Hashtags       []string `json:"hashtags"`
+	WorkInProgress bool     `json:"work_in_progress"`
+	MoreChanges    bool     `json:"_more_changes"`
+}
+
+// NewGerritPoller creates a poller for the open changes of a Gerrit project. The access token is the HTTP password
+// of the given username. Without credentials the changes are queried anonymously.
+func NewGerritPoller(endpoint string, username string, accessToken string, transportOptions TransportOptions, project string) *GerritPoller {
+	return &GerritPoller{
+		Endpoint:    endpoint,
+		Username:    username,
+		AccessToken: accessToken,
+		Transport:   transportOptions,
+		Project:     project,
+	}
+}
+
+func (gerritPoller GerritPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	httpClient, err := newHTTPClient(gerritPoller.Transport)
+	if err != nil {
+		return pullrequestv1alpha1.Branches{}, "", err
+	}
+
+	var branches pullrequestv1alpha1.Branches
+
+	baseUrl, err := url.Parse(gerritPoller.changesUrl())
+	if err != nil {
+		return branches, "", err
+	}
+
+	var sourceBranches []pullrequestv1alpha1.Branch
+	// the page size is bounded by the query limit of the user, the next page starts after the listed changes
+	for skip := 0; ; skip = len(sourceBranches) {
+		query := url.Values{}
+		// without a branch, the changes of all target branches are listed
+		changeQuery := "status:open project:" + gerritPoller.Project
+		if len(branch) > 0 {
+			changeQuery += " branch:" + trimBranchRef(branch)
+		}
+		query.Set("q", changeQuery)
+		query.Set("o", "CURRENT_REVISION")
+		query.Set("n", strconv.Itoa(gerritPageSize))
+		query.Set("S", strconv.Itoa(skip))
+		baseUrl.RawQuery = query.Encode()
+
+		req, err := gerritPoller.newRequest(ctx, baseUrl.String())
+		if err != nil {
+			return branches, "", err
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return branches, "", err
+		}
+		var changeList []json.RawMessage
+		err = decodeGerritResponse(resp, &changeList)
+		if err != nil {
+			return branches, "", err
+		}
+
+		moreChanges := false
+		for i := 0; i < len(changeList); i++ {
+			var change gerritChange
+			if err := json.Unmarshal(changeList[i], &change); err != nil {
+				return branches, "", err
+			}
+			var tempBranch pullrequestv1alpha1.Branch
+			tempBranch.ID = strconv.Itoa(change.Number)
+			// the change ref of the current patch set, e.g. refs/changes/45/12345/2
+			tempBranch.Name = change.Revisions[change.CurrentRevision].Ref
+			tempBranch.Commit = change.CurrentRevision
+			tempBranch.TargetBranch = change.Branch
+			tempBranch.Labels = change.Hashtags
+			tempBranch.Draft = change.WorkInProgress
+			tempBranch.Details = string(changeList[i])
+			sourceBranches = append(sourceBranches, tempBranch)
+			moreChanges = change.MoreChanges
+		}
+
+		if !moreChanges {
+			break
+		}
+	}
+
+	branches.Branches = sourceBranches
+
+	return branches, "", nil
+}
+
+func (gerritPoller GerritPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	httpClient, err := newHTTPClient(gerritPoller.Transport)
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := gerritPoller.newRequest(ctx, gerritPoller.changesUrl()+url.PathEscape(gerritPoller.Project+"~"+id))
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	var change gerritChange
+	if err := decodeGerritResponse(resp, &change); err != nil {
+		return "", "", err
+	}
+
+	// Gerrit does not report a merge commit, depending on the submit type the change is cherry-picked or rebased
+	switch change.Status {
+	case "NEW":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	case "MERGED":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, "", nil
+	case "ABANDONED":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_DECLINED, "", nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+// changesUrl returns the url of the changes endpoint, authenticated requests have to use the /a/ prefix
+func (gerritPoller GerritPoller) changesUrl() string {
+	if len(gerritPoller.Username) > 0 {
+		return strings.TrimSuffix(gerritPoller.Endpoint, "/") + "/a/changes/"
+	}
+	return strings.TrimSuffix(gerritPoller.Endpoint, "/") + "/changes/"
+}
+
+func (gerritPoller GerritPoller) newRequest(ctx context.Context, requestUrl string) (*http.Request, error) {
+	// the access token is the HTTP password of the user, without username the changes would be queried anonymously
+	if len(gerritPoller.Username) == 0 && len(gerritPoller.AccessToken) > 0 {
+		return nil, errors.New("the HTTP password of Gerrit requires a username")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(gerritPoller.Username) > 0 {
+		req.SetBasicAuth(strings.TrimSuffix(gerritPoller.Username, "\n"), strings.TrimSuffix(gerritPoller.AccessToken, "\n"))
+	}
+	return req, nil
+}
+
+func decodeGerritResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes.TrimPrefix(body, []byte(gerritMagicPrefix)), v)
+}
@@ -0,0 +1,120 @@
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGerritPollerPollMoreChanges(t *testing.T) {
+	var skips []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/a/changes/" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("q") != "status:open project:microservice branch:main" || r.URL.Query().Get("o") != "CURRENT_REVISION" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		if username, password, ok := r.BasicAuth(); !ok || username != "jquad" || password != "secret" {
+			t.Errorf("unexpected basic auth %s/%s", username, password)
+		}
+		skips = append(skips, r.URL.Query().Get("S"))
+		w.Header().Set("Content-Type", "application/json")
+		// the query limit of the user is below the page size, the last change of a page is flagged if more follow
+		switch r.URL.Query().Get("S") {
+		case "0":
+			w.Write([]byte(")]}'\n" + `[
+				{"_number": 1, "status": "NEW", "branch": "main", "current_revision": "1111111111111111111111111111111111111111", "revisions": {"1111111111111111111111111111111111111111": {"ref": "refs/changes/01/1/2"}}, "hashtags": ["ci/run"], "work_in_progress": true},
+				{"_number": 2, "status": "NEW", "branch": "main", "current_revision": "2222222222222222222222222222222222222222", "revisions": {"2222222222222222222222222222222222222222": {"ref": "refs/changes/02/2/1"}}, "_more_changes": true}
+			]`))
+		case "2":
+			w.Write([]byte(")]}'\n" + `[{"_number": 3, "status": "NEW", "branch": "main", "current_revision": "3333333333333333333333333333333333333333", "revisions": {"3333333333333333333333333333333333333333": {"ref": "refs/changes/03/3/1"}}}]`))
+		default:
+			t.Errorf("unexpected start %s", r.URL.Query().Get("S"))
+			w.Write([]byte(")]}'\n[]"))
+		}
+	}))
+	defer server.Close()
+
+	poller := NewGerritPoller(server.URL, "jquad\n", "secret\n", TransportOptions{}, "microservice")
+	branches, _, err := poller.Poll(context.Background(), "refs/heads/main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the next page starts after the changes of the previous pages
+	if strings.Join(skips, ",") != "0,2" {
+		t.Errorf("expected the pages to start after the listed changes, got %v", skips)
+	}
+	if branches.GetSize() != 3 {
+		t.Fatalf("expected the changes of all pages, got %d", branches.GetSize())
+	}
+	first := branches.Branches[0]
+	if first.ID != "1" || first.Name != "refs/changes/01/1/2" || first.Commit != "1111111111111111111111111111111111111111" || first.TargetBranch != "main" || !first.Draft || len(first.Labels) != 1 || first.Details == "" {
+		t.Errorf("unexpected branch %+v", first)
+	}
+}
+
+func TestGerritPollerRequiresUsername(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected anonymous request %s", r.URL)
+	}))
+	defer server.Close()
+
+	// the access token is the HTTP password of a user, it is never dropped silently
+	poller := NewGerritPoller(server.URL, "", "secret", TransportOptions{}, "microservice")
+	if _, _, err := poller.Poll(context.Background(), "main", ""); err == nil {
+		t.Error("expected an error for an access token without username")
+	}
+}
+
+func TestGerritPollerPollError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	poller := NewGerritPoller(server.URL, "jquad", "wrong", TransportOptions{}, "microservice")
+	_, _, err := poller.Poll(context.Background(), "main", "")
+	if statusCode, ok := StatusCode(err); !ok || statusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestGerritPollerGetState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/changes/microservice~1":
+			w.Write([]byte(")]}'\n" + `{"_number": 1, "status": "MERGED"}`))
+		case "/changes/microservice~2":
+			w.Write([]byte(")]}'\n" + `{"_number": 2, "status": "ABANDONED"}`))
+		case "/changes/microservice~3":
+			w.Write([]byte(")]}'\n" + `{"_number": 3, "status": "NEW"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// without credentials the changes are queried anonymously
+	poller := NewGerritPoller(server.URL, "", "", TransportOptions{}, "microservice")
+	for id, expected := range map[string]string{
+		"1": "merged",
+		"2": "declined",
+		"3": "open",
+	} {
+		state, mergeCommit, err := poller.GetState(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// gerrit does not report a merge commit
+		if state != expected || mergeCommit != "" {
+			t.Errorf("%s: expected %s without merge commit, got %s with %q", id, expected, state, mergeCommit)
+		}
+	}
+
+	if _, _, err := poller.GetState(context.Background(), "4"); err == nil {
+		t.Error("expected an error for an unknown change")
+	}
+}
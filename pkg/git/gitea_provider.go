@@ -0,0 +1,156 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+const (
+	giteaApiPath  = "/api/v1"
+	giteaPageSize = 50
+)
+
+type GiteaPoller struct {
+	Endpoint    string
+	AccessToken string
+	Transport   TransportOptions
+	Owner       string
+	Repository  string
+}
+
+type giteaPullRequest struct {
+	Number         int    `json:"number"`
+	State          string `json:"state"`
+	Merged         bool   `json:"merged"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	Base           struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Head struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+func NewGiteaPoller(endpoint string, accessToken string, transportOptions TransportOptions, owner string, repository string) *GiteaPoller {
+	return &GiteaPoller{
+		Endpoint:    endpoint,
+		AccessToken: accessToken,
+		Transport:   transportOptions,
+		Owner:       owner,
+		Repository:  repository,
+	}
+}
+
+func (giteaPoller GiteaPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	httpClient, err := newHTTPClient(giteaPoller.Transport)
+	if err != nil {
+		return pullrequestv1alpha1.Branches{}, "", err
+	}
+
+	var branches pullrequestv1alpha1.Branches
+
+	firstPage, err := url.Parse(giteaPoller.pullsUrl())
+	if err != nil {
+		return branches, "", err
+	}
+	query := url.Values{}
+	query.Set("state", "open")
+	query.Set("limit", strconv.Itoa(giteaPageSize))
+	firstPage.RawQuery = query.Encode()
+
+	var sourceBranches []pullrequestv1alpha1.Branch
+	// the pulls endpoint cannot filter by base branch, so all open pull requests are listed and filtered here. The
+	// server may clamp the limit to its maximum page size, the next page is taken from the Link header.
+	for pageUrl := firstPage.String(); pageUrl != ""; {
+		req, err := giteaPoller.newRequest(ctx, pageUrl)
+		if err != nil {
+			return branches, "", err
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return branches, "", err
+		}
+		var prList []json.RawMessage
+		err = decodeJSONResponse(resp, &prList)
+		if err != nil {
+			return branches, "", err
+		}
+
+		for i := 0; i < len(prList); i++ {
+			var pr giteaPullRequest
+			if err := json.Unmarshal(prList[i], &pr); err != nil {
+				return branches, "", err
+			}
+			// without a branch, the pull requests of all target branches are listed
+			if len(branch) > 0 && pr.Base.Ref != trimBranchRef(branch) {
+				continue
+			}
+			var tempBranch pullrequestv1alpha1.Branch
+			tempBranch.ID = strconv.Itoa(pr.Number)
+			tempBranch.Name = pr.Head.Ref
+			tempBranch.Commit = pr.Head.SHA
+			tempBranch.TargetBranch = pr.Base.Ref
+			for _, label := range pr.Labels {
+				tempBranch.Labels = append(tempBranch.Labels, label.Name)
+			}
+			tempBranch.Details = string(prList[i])
+			sourceBranches = append(sourceBranches, tempBranch)
+		}
+
+		pageUrl = nextLink(resp.Header)
+	}
+
+	branches.Branches = sourceBranches
+
+	return branches, "", nil
+}
+
+func (giteaPoller GiteaPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	httpClient, err := newHTTPClient(giteaPoller.Transport)
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := giteaPoller.newRequest(ctx, giteaPoller.pullsUrl()+"/"+url.PathEscape(id))
+	if err != nil {
+		return "", "", err
+	}
+	var pr giteaPullRequest
+	if err := getJSON(httpClient, req, &pr); err != nil {
+		return "", "", err
+	}
+
+	if pr.Merged {
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, pr.MergeCommitSHA, nil
+	}
+	if pr.State == "open" {
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+func (giteaPoller GiteaPoller) pullsUrl() string {
+	return strings.TrimSuffix(giteaPoller.Endpoint, "/") + giteaApiPath + "/repos/" + url.PathEscape(giteaPoller.Owner) + "/" + url.PathEscape(giteaPoller.Repository) + "/pulls"
+}
+
+func (giteaPoller GiteaPoller) newRequest(ctx context.Context, requestUrl string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(giteaPoller.AccessToken) > 0 {
+		req.Header.Set("Authorization", "token "+strings.TrimSuffix(giteaPoller.AccessToken, "\n"))
+	}
+	return req, nil
+}
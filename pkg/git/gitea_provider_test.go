@@ -0,0 +1,143 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const giteaPullsResponse = `[
+	{"number": 3, "state": "open", "base": {"ref": "main"}, "head": {"ref": "feature-a", "sha": "1111111111111111111111111111111111111111"}, "labels": [{"name": "ci/run"}]},
+	{"number": 2, "state": "open", "base": {"ref": "develop"}, "head": {"ref": "feature-b", "sha": "2222222222222222222222222222222222222222"}},
+	{"number": 1, "state": "open", "base": {"ref": "main"}, "head": {"ref": "feature-c", "sha": "3333333333333333333333333333333333333333"}}
+]`
+
+func TestGiteaPollerPoll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repos/jquad/microservice/pulls" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("state") != "open" {
+			t.Errorf("expected state=open, got %s", r.URL.Query().Get("state"))
+		}
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(giteaPullsResponse))
+	}))
+	defer server.Close()
+
+	poller := NewGiteaPoller(server.URL, "secret\n", TransportOptions{}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "refs/heads/main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branches.GetSize() != 2 {
+		t.Fatalf("expected 2 branches, got %d", branches.GetSize())
+	}
+	if branches.Branches[0].Name != "feature-a" || branches.Branches[0].Commit != "1111111111111111111111111111111111111111" || len(branches.Branches[0].Labels) != 1 || branches.Branches[0].Labels[0] != "ci/run" {
+		t.Errorf("unexpected branch %+v", branches.Branches[0])
+	}
+	if branches.Branches[1].Name != "feature-c" || branches.Branches[1].Details == "" {
+		t.Errorf("unexpected branch %+v", branches.Branches[1])
+	}
+}
+
+func TestGiteaPollerPollError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	poller := NewGiteaPoller(server.URL, "wrong", TransportOptions{}, "jquad", "microservice")
+	if _, _, err := poller.Poll(context.Background(), "main", ""); err == nil {
+		t.Fatal("expected an error for an unauthorized request")
+	}
+}
+
+func TestGiteaPollerGetState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/jquad/microservice/pulls/1":
+			w.Write([]byte(`{"number": 1, "state": "closed", "merged": true, "merge_commit_sha": "4444444444444444444444444444444444444444"}`))
+		case "/api/v1/repos/jquad/microservice/pulls/2":
+			w.Write([]byte(`{"number": 2, "state": "closed", "merged": false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	poller := NewGiteaPoller(server.URL, "", TransportOptions{}, "jquad", "microservice")
+
+	state, mergeCommit, err := poller.GetState(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "merged" || mergeCommit != "4444444444444444444444444444444444444444" {
+		t.Errorf("unexpected state %s with merge commit %s", state, mergeCommit)
+	}
+
+	state, _, err = poller.GetState(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "closed" {
+		t.Errorf("unexpected state %s", state)
+	}
+
+	if _, _, err := poller.GetState(context.Background(), "3"); err == nil {
+		t.Error("expected an error for an unknown pull request")
+	}
+}
+
+func TestGiteaPollerPollAllTargetBranches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(giteaPullsResponse))
+	}))
+	defer server.Close()
+
+	poller := NewGiteaPoller(server.URL, "", TransportOptions{}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 3 {
+		t.Fatalf("expected 3 branches, got %d", branches.GetSize())
+	}
+	if branches.Branches[1].TargetBranch != "develop" {
+		t.Errorf("unexpected target branch %s", branches.Branches[1].TargetBranch)
+	}
+}
+
+func TestGiteaPollerPollLinkHeader(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server clamps the limit to its MAX_RESPONSE_ITEMS of 2 and links the next page
+		w.Header().Set("X-Total-Count", "3")
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?limit=2&page=2&state=open>; rel="next",<%s%s?limit=2&page=2&state=open>; rel="last"`, server.URL, r.URL.Path, server.URL, r.URL.Path))
+			w.Write([]byte(`[
+				{"number": 3, "state": "open", "base": {"ref": "main"}, "head": {"ref": "feature-a", "sha": "1111111111111111111111111111111111111111"}},
+				{"number": 2, "state": "open", "base": {"ref": "develop"}, "head": {"ref": "feature-b", "sha": "2222222222222222222222222222222222222222"}}
+			]`))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s?limit=2&page=1&state=open>; rel="first",<%s%s?limit=2&page=1&state=open>; rel="prev"`, server.URL, r.URL.Path, server.URL, r.URL.Path))
+		w.Write([]byte(`[{"number": 1, "state": "open", "base": {"ref": "main"}, "head": {"ref": "feature-c", "sha": "3333333333333333333333333333333333333333"}}]`))
+	}))
+	defer server.Close()
+
+	poller := NewGiteaPoller(server.URL, "", TransportOptions{}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || branches.Branches[1].Name != "feature-c" {
+		t.Errorf("expected the pull requests to main of both pages, got %+v", branches.Branches)
+	}
+}
@@ -0,0 +1,132 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// the jwt of a github app is valid for at most 10 minutes, keep a margin for clock drift
+	githubAppJWTExpiry = 9 * time.Minute
+	// installation tokens are valid for one hour and are refreshed before they expire
+	githubAppTokenRefreshBefore = 5 * time.Minute
+)
+
+// GithubApp holds the credentials of a github app installation
+type GithubApp struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     []byte
+}
+
+// installation token of a github app installation
+type githubAppToken struct {
+	// serializes the minting of tokens per installation, so that a slow installation does not block other ones
+	sync.Mutex
+	token     string
+	expiresAt time.Time
+	// digest of the private key, which signed the token
+	privateKeyHash string
+}
+
+// installation tokens are shared by all pollers using the same app installation
+var githubAppTokens = struct {
+	sync.Mutex
+	tokens map[string]*githubAppToken
+}{tokens: map[string]*githubAppToken{}}
+
+// NewGithubApp parses the app and installation id of a github app
+func NewGithubApp(appID string, installationID string, privateKey []byte) (*GithubApp, error) {
+	parsedAppID, err := strconv.ParseInt(appID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app id %q: %w", appID, err)
+	}
+	parsedInstallationID, err := strconv.ParseInt(installationID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app installation id %q: %w", installationID, err)
+	}
+	// an invalid private key is reported with the creation of the poller, not with each poll
+	if _, err := jwt.ParseRSAPrivateKeyFromPEM(privateKey); err != nil {
+		return nil, fmt.Errorf("invalid github app private key: %w", err)
+	}
+	return &GithubApp{
+		AppID:          parsedAppID,
+		InstallationID: parsedInstallationID,
+		PrivateKey:     privateKey,
+	}, nil
+}
+
+// installationToken returns a cached installation token, or mints a new one if the cached token expires soon
+func (githubPoller GithubPoller) installationToken(ctx context.Context, transport http.RoundTripper) (string, error) {
+	app := githubPoller.App
+	privateKeyDigest := sha256.Sum256(app.PrivateKey)
+	privateKeyHash := hex.EncodeToString(privateKeyDigest[:])
+	key := githubPoller.Endpoint + "/" + strconv.FormatInt(app.AppID, 10) + "/" + strconv.FormatInt(app.InstallationID, 10)
+
+	githubAppTokens.Lock()
+	cached, ok := githubAppTokens.tokens[key]
+	if !ok {
+		cached = &githubAppToken{}
+		githubAppTokens.tokens[key] = cached
+	}
+	githubAppTokens.Unlock()
+
+	cached.Lock()
+	defer cached.Unlock()
+	// a token signed with a rotated private key is replaced
+	if cached.privateKeyHash == privateKeyHash && time.Until(cached.expiresAt) > githubAppTokenRefreshBefore {
+		return cached.token, nil
+	}
+
+	appJWT, err := app.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+	client, err := newGithubClient(githubPoller.Endpoint, &http.Client{Transport: &bearerTransport{token: appJWT, transport: transport}, Timeout: githubPoller.Transport.timeout()})
+	if err != nil {
+		return "", err
+	}
+	installationToken, _, err := client.Apps.CreateInstallationToken(ctx, app.InstallationID, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create a github app installation token: %w", err)
+	}
+
+	cached.token = installationToken.GetToken()
+	cached.expiresAt = installationToken.GetExpiresAt()
+	cached.privateKeyHash = privateKeyHash
+	return cached.token, nil
+}
+
+// signJWT creates the jwt authenticating as the github app
+func (app *GithubApp) signJWT(now time.Time) (string, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(app.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid github app private key: %w", err)
+	}
+	claims := jwt.RegisteredClaims{
+		// backdate the token to allow for clock drift
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(githubAppJWTExpiry)),
+		Issuer:    strconv.FormatInt(app.AppID, 10),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+}
+
+type bearerTransport struct {
+	token     string
+	transport http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,182 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// newGithubAppServer serves the installation token endpoint and the pull requests of a github enterprise api,
+// installation tokens expire after tokenLifetime
+func newGithubAppServer(t *testing.T, privateKey *rsa.PrivateKey, tokenLifetime time.Duration, mintedTokens *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/app/installations/42/access_tokens":
+			appJWT := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			claims := &jwt.RegisteredClaims{}
+			_, err := jwt.ParseWithClaims(appJWT, claims, func(token *jwt.Token) (interface{}, error) {
+				return &privateKey.PublicKey, nil
+			})
+			if err != nil || claims.Issuer != "1234" {
+				t.Errorf("invalid app jwt %q: %v", appJWT, err)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			*mintedTokens++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": "%s"}`, *mintedTokens, time.Now().Add(tokenLifetime).UTC().Format(time.RFC3339))
+		case "/api/v3/repos/jquad/microservice/pulls":
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer ghs_%d", *mintedTokens) {
+				t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`[{"number": 1, "head": {"ref": "feature-a", "sha": "1111111111111111111111111111111111111111"}}]`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newGithubAppTestKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+}
+
+func TestGithubAppPollerCachesInstallationToken(t *testing.T) {
+	privateKey, privateKeyPEM := newGithubAppTestKey(t)
+	mintedTokens := 0
+	server := newGithubAppServer(t, privateKey, time.Hour, &mintedTokens)
+	defer server.Close()
+
+	app, err := NewGithubApp("1234", "42", privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		// a new poller is created for each reconciliation
+		poller := NewGithubAppPoller(server.URL, app, TransportOptions{}, "jquad", "microservice")
+		branches, _, err := poller.Poll(context.Background(), "main", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if branches.GetSize() != 1 {
+			t.Fatalf("expected 1 branch, got %d", branches.GetSize())
+		}
+	}
+	if mintedTokens != 1 {
+		t.Errorf("expected the installation token to be minted once, got %d", mintedTokens)
+	}
+}
+
+func TestGithubAppPollerRefreshesInstallationToken(t *testing.T) {
+	privateKey, privateKeyPEM := newGithubAppTestKey(t)
+	mintedTokens := 0
+	// the token expires within the refresh margin and is minted again for each poll
+	server := newGithubAppServer(t, privateKey, time.Minute, &mintedTokens)
+	defer server.Close()
+
+	app, err := NewGithubApp("1234", "42", privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poller := NewGithubAppPoller(server.URL, app, TransportOptions{}, "jquad", "microservice")
+	for i := 0; i < 2; i++ {
+		if _, _, err := poller.Poll(context.Background(), "main", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if mintedTokens != 2 {
+		t.Errorf("expected the installation token to be refreshed, got %d minted tokens", mintedTokens)
+	}
+}
+
+func TestNewGithubAppInvalidID(t *testing.T) {
+	if _, err := NewGithubApp("my-app", "42", nil); err == nil {
+		t.Error("expected an error for a non-numeric app id")
+	}
+}
+
+func TestNewGithubAppInvalidPrivateKey(t *testing.T) {
+	if _, err := NewGithubApp("1234", "42", []byte("not a private key")); err == nil {
+		t.Error("expected an error for an invalid private key")
+	}
+}
+
+func TestGithubAppInstallationTokenDoesNotBlockOtherInstallations(t *testing.T) {
+	_, privateKeyPEM := newGithubAppTestKey(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// the token of the installation 7 is minted slowly
+		if r.URL.Path == "/api/v3/app/installations/7/access_tokens" {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%s", "expires_at": "%s"}`, strings.Split(r.URL.Path, "/")[5], time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	slowPoller := GithubPoller{Endpoint: server.URL, App: &GithubApp{AppID: 1234, InstallationID: 7, PrivateKey: privateKeyPEM}}
+	go slowPoller.installationToken(context.Background(), http.DefaultTransport)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	poller := GithubPoller{Endpoint: server.URL, App: &GithubApp{AppID: 1234, InstallationID: 8, PrivateKey: privateKeyPEM}}
+	token, err := poller.installationToken(ctx, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "ghs_8" {
+		t.Errorf("expected the token ghs_8, got %s", token)
+	}
+}
+
+func TestGithubAppPollerRotatedPrivateKey(t *testing.T) {
+	privateKey, privateKeyPEM := newGithubAppTestKey(t)
+	mintedTokens := 0
+	server := newGithubAppServer(t, privateKey, time.Hour, &mintedTokens)
+	defer server.Close()
+
+	app, err := NewGithubApp("1234", "42", privateKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewGithubAppPoller(server.URL, app, TransportOptions{}, "jquad", "microservice").Poll(context.Background(), "main", ""); err != nil {
+		t.Fatal(err)
+	}
+	// the rotated key is another encoding of the same key, which the server still verifies
+	rotated, err := NewGithubApp("1234", "42", append(privateKeyPEM, '\n'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewGithubAppPoller(server.URL, rotated, TransportOptions{}, "jquad", "microservice").Poll(context.Background(), "main", ""); err != nil {
+		t.Fatal(err)
+	}
+	if mintedTokens != 2 {
+		t.Errorf("expected the installation token to be minted again for the rotated key, got %d minted tokens", mintedTokens)
+	}
+	// the token of the previous key is replaced instead of kept next to the new one
+	githubAppTokens.Lock()
+	defer githubAppTokens.Unlock()
+	if cached := githubAppTokens.tokens[server.URL+"/1234/42"]; cached == nil || cached.token != "ghs_2" {
+		t.Errorf("expected the token of the rotated key to replace the cached token")
+	}
+}
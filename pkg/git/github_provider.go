@@ -2,116 +2,106 @@ package v1alpha1
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	githubClient "github.com/google/go-github/v42/github"
 	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
 	"golang.org/x/oauth2"
 )
 
+const (
+	// maximum page size of the github api
+	githubPageSize = 100
+)
+
 type GithubPoller struct {
-	Endpoint           string
-	AccessToken        string
-	InsecureSkipVerify bool
-	Owner              string
-	Repository         string
+	Endpoint    string
+	AccessToken string
+	Transport   TransportOptions
+	Owner       string
+	Repository  string
+	// App authenticates as github app installation instead of with the access token, if set
+	App *GithubApp
 }
 
-func NewGithubPoller(endpoint string, accessToken string, insecureSkipVerify bool, owner string, repository string) *GithubPoller {
+func NewGithubPoller(endpoint string, accessToken string, transportOptions TransportOptions, owner string, repository string) *GithubPoller {
 	return &GithubPoller{
-		Endpoint:           endpoint,
-		AccessToken:        accessToken,
-		InsecureSkipVerify: insecureSkipVerify,
-		Owner:              owner,
-		Repository:         repository,
+		Endpoint:    endpoint,
+		AccessToken: accessToken,
+		Transport:   transportOptions,
+		Owner:       owner,
+		Repository:  repository,
 	}
 }
 
-func (githubPoller GithubPoller) Poll(branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
-	ctx := context.Background()
-	/*
-		transportHeaders := transportHeaders{
-			eTag: etag,
-		}
-	*/
-
-	// check if we accept untrusted certificates
-	var httpTransport *http.Transport
-	if githubPoller.InsecureSkipVerify {
-		httpTransport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+func NewGithubAppPoller(endpoint string, app *GithubApp, transportOptions TransportOptions, owner string, repository string) *GithubPoller {
+	githubPoller := NewGithubPoller(endpoint, "", transportOptions, owner, repository)
+	githubPoller.App = app
+	return githubPoller
+}
 
-	} else {
-		httpTransport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
-		}
+// Poll returns no pull requests together with the given etag, if the pull requests did not change since the poll which
+// returned the etag
+func (githubPoller GithubPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	ctx = withRateLimit(ctx, githubPoller.Transport.RateLimit)
+	var branches pullrequestv1alpha1.Branches
+	client, err := githubPoller.newClient(ctx)
+	if err != nil {
+		return branches, "", err
 	}
 
-	httpClient := &http.Client{Transport: &transportHeaders{eTag: etag, transport: httpTransport}}
+	opts := githubClient.PullRequestListOptions{Base: branch, ListOptions: githubClient.ListOptions{PerPage: githubPageSize}}
 
-	var tc *http.Client
-	// check if we provided an access token
-	if len(githubPoller.AccessToken) > 0 {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: githubPoller.AccessToken},
-		)
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-		tc = oauth2.NewClient(ctx, ts)
-	} else {
-		tc = nil
+	// the etag only applies to the first page, so it is only returned, if the listing fits on one page. Otherwise a
+	// change on a following page would be missed.
+	page, err := githubPoller.listFirstPage(ctx, client, &opts, etag)
+	if err != nil {
+		return branches, "", err
 	}
-
-	var branches pullrequestv1alpha1.Branches
-	var client *githubClient.Client
-	var errClient error
-	// check if the base url is github.com or an enterprise github server
-	if !strings.HasPrefix(githubPoller.Endpoint, "https://github.com/") {
-		gheEndpoint, err := url.Parse(githubPoller.Endpoint)
-		if err != nil {
-			fmt.Println(err)
-		}
-		client, errClient = githubClient.NewEnterpriseClient(gheEndpoint.Scheme+"://"+gheEndpoint.Host, gheEndpoint.Scheme+"://"+gheEndpoint.Host, tc)
-		if errClient != nil {
-			fmt.Println(errClient)
-			return branches, "", errClient
-		}
-	} else {
-		client = githubClient.NewClient(tc)
+	recordListing(pullrequestv1alpha1.GITHUB_PROVIDER_NAME, page.notModified)
+	if page.notModified {
+		branches.NotModified = true
+		return branches, etag, nil
 	}
 
-	opts := githubClient.PullRequestListOptions{Base: branch}
-
-	var prList []*githubClient.PullRequest
-	var prResponse *githubClient.Response
-	prList, prResponse, err := client.PullRequests.List(ctx, githubPoller.Owner, githubPoller.Repository, &opts)
-	eTagUnparsed := prResponse.Header.Get("ETag")
-	eTag := ""
-	if strings.Contains(eTagUnparsed, "W/") {
-		eTag = strings.Split(prResponse.Header.Get("ETag"), "/")[1]
-	} else {
-		eTag = prResponse.Header.Get("ETag")
-	}
-	if prResponse.StatusCode == http.StatusNotModified {
-		return branches, eTag, nil
+	prList := page.pullRequests
+	nextPage := page.nextPage
+	eTag := page.eTag
+	if nextPage != 0 {
+		eTag = ""
 	}
-	if err != nil {
-		fmt.Println(prResponse)
-		fmt.Println(err)
-		return branches, "", err
+	for nextPage != 0 {
+		opts.Page = nextPage
+		nextPrList, prResponse, err := client.PullRequests.List(ctx, githubPoller.Owner, githubPoller.Repository, &opts)
+		if err != nil {
+			return branches, "", githubRateLimitError(err)
+		}
+		prList = append(prList, nextPrList...)
+		nextPage = prResponse.NextPage
 	}
 
 	sourceBranches := make([]pullrequestv1alpha1.Branch, len(prList))
 
 	for i := 0; i < len(prList); i++ {
 		var tempBranch pullrequestv1alpha1.Branch
+		tempBranch.ID = strconv.Itoa(prList[i].GetNumber())
 		tempBranch.Name = prList[i].GetHead().GetRef()
 		tempBranch.Commit = prList[i].GetHead().GetSHA()
+		tempBranch.TargetBranch = prList[i].GetBase().GetRef()
+		tempBranch.Draft = prList[i].GetDraft()
+		// the head repository is missing, if the fork was deleted
+		tempBranch.Fork = prList[i].GetHead().GetRepo().GetFullName() != prList[i].GetBase().GetRepo().GetFullName()
+		tempBranch.Author = prList[i].GetUser().GetLogin()
+		for _, label := range prList[i].Labels {
+			tempBranch.Labels = append(tempBranch.Labels, label.GetName())
+		}
 		pr, err := json.Marshal(prList[i])
 		if err != nil {
 			//fmt.Println(err)
@@ -125,16 +115,221 @@ func (githubPoller GithubPoller) Poll(branch string, etag string) (pullrequestv1
 	return branches, eTag, nil
 }
 
+// githubPage is the first page of a conditional listing of the pull requests
+type githubPage struct {
+	pullRequests []*githubClient.PullRequest
+	nextPage     int
+	// eTag is sent as is with the next listing, weak etags keep the W/ prefix
+	eTag string
+	// notModified is true, if the pull requests did not change since the listing with the etag
+	notModified bool
+}
+
+func (githubPoller GithubPoller) listFirstPage(ctx context.Context, client *githubClient.Client, opts *githubClient.PullRequestListOptions, etag string) (githubPage, error) {
+	prList, prResponse, err := client.PullRequests.List(withETag(ctx, etag), githubPoller.Owner, githubPoller.Repository, opts)
+	// no response was received, e.g. because the certificate of the server could not be verified
+	if prResponse == nil || prResponse.Response == nil {
+		if err == nil {
+			err = fmt.Errorf("no response from %s", githubPoller.Endpoint)
+		}
+		return githubPage{}, githubRateLimitError(err)
+	}
+	// the github client reports 304 Not Modified as error
+	if prResponse.StatusCode == http.StatusNotModified && len(etag) > 0 {
+		return githubPage{eTag: etag, notModified: true}, nil
+	}
+	if err != nil {
+		return githubPage{}, githubRateLimitError(err)
+	}
+	return githubPage{
+		pullRequests: prList,
+		nextPage:     prResponse.NextPage,
+		eTag:         prResponse.Header.Get("ETag"),
+	}, nil
+}
+
+func (githubPoller GithubPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	ctx = withRateLimit(ctx, githubPoller.Transport.RateLimit)
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return "", "", err
+	}
+	client, err := githubPoller.newClient(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, githubPoller.Owner, githubPoller.Repository, number)
+	if err != nil {
+		return "", "", err
+	}
+
+	if pr.GetMerged() {
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, pr.GetMergeCommitSHA(), nil
+	}
+	if pr.GetState() == "open" {
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+func (githubPoller GithubPoller) IsOrganizationMember(ctx context.Context, organization string, user string) (bool, error) {
+	ctx = withRateLimit(ctx, githubPoller.Transport.RateLimit)
+	client, err := githubPoller.newClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	member, _, err := client.Organizations.IsMember(ctx, organization, user)
+	if err != nil {
+		return false, githubRateLimitError(err)
+	}
+	return member, nil
+}
+
+func (githubPoller GithubPoller) IsTeamMember(ctx context.Context, organization string, team string, user string) (bool, error) {
+	ctx = withRateLimit(ctx, githubPoller.Transport.RateLimit)
+	client, err := githubPoller.newClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	membership, _, err := client.Teams.GetTeamMembershipBySlug(ctx, organization, team, user)
+	if statusCode, ok := StatusCode(err); ok && statusCode == http.StatusNotFound {
+		// the user is not a member of the team
+		return false, nil
+	}
+	if err != nil {
+		return false, githubRateLimitError(err)
+	}
+	return membership.GetState() == "active", nil
+}
+
+func (githubPoller GithubPoller) ListComments(ctx context.Context, id string) ([]Comment, error) {
+	ctx = withRateLimit(ctx, githubPoller.Transport.RateLimit)
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := githubPoller.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the comments of the conversation, the review comments on the code are not listed
+	var comments []Comment
+	opts := githubClient.IssueListCommentsOptions{ListOptions: githubClient.ListOptions{PerPage: githubPageSize}}
+	for {
+		page, response, err := client.Issues.ListComments(ctx, githubPoller.Owner, githubPoller.Repository, number, &opts)
+		if err != nil {
+			return nil, githubRateLimitError(err)
+		}
+		for _, comment := range page {
+			comments = append(comments, Comment{Author: comment.GetUser().GetLogin(), Body: comment.GetBody(), Created: comment.GetCreatedAt()})
+		}
+		if response.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = response.NextPage
+	}
+}
+
+// GetCommitTime returns the zero time, github does not report when a commit was pushed and the commit date is set
+// by the author of the commit
+func (githubPoller GithubPoller) GetCommitTime(ctx context.Context, id string, commit string) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// newClient returns the cached client for github.com or an enterprise github server
+func (githubPoller GithubPoller) newClient(ctx context.Context) (*githubClient.Client, error) {
+	accessToken := githubPoller.AccessToken
+	if githubPoller.App != nil {
+		// the transport is shared with pollers with the same TLS and proxy settings only
+		httpTransport, err := getTransport(githubPoller.Transport)
+		if err != nil {
+			return nil, err
+		}
+		token, err := githubPoller.installationToken(ctx, httpTransport)
+		if err != nil {
+			return nil, err
+		}
+		accessToken = token
+	}
+
+	client, err := getClient(githubPoller.Endpoint, githubPoller.Owner+"/"+githubPoller.Repository, accessToken, githubPoller.Transport, func(transport http.RoundTripper) (interface{}, error) {
+		httpClient := &http.Client{Transport: &transportHeaders{transport: transport}, Timeout: githubPoller.Transport.timeout()}
+
+		var tc *http.Client
+		// check if we provided an access token
+		if len(accessToken) > 0 {
+			ts := oauth2.StaticTokenSource(
+				&oauth2.Token{AccessToken: accessToken},
+			)
+			tc = oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, httpClient), ts)
+			tc.Timeout = httpClient.Timeout
+		} else {
+			// never fall back to the default client, which would ignore the TLS and proxy settings
+			tc = httpClient
+		}
+		return newGithubClient(githubPoller.Endpoint, tc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*githubClient.Client), nil
+}
+
+// newGithubClient creates a client for github.com or, for any other endpoint, an enterprise github server
+func newGithubClient(endpoint string, httpClient *http.Client) (*githubClient.Client, error) {
+	if !strings.HasPrefix(endpoint, "https://github.com/") {
+		gheEndpoint, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return githubClient.NewEnterpriseClient(gheEndpoint.Scheme+"://"+gheEndpoint.Host, gheEndpoint.Scheme+"://"+gheEndpoint.Host, httpClient)
+	}
+	return githubClient.NewClient(httpClient), nil
+}
+
+type eTagContextKey struct{}
+
+// withETag sends the etag with the requests of the context, the client itself is shared and does not hold an etag
+func withETag(ctx context.Context, eTag string) context.Context {
+	return context.WithValue(ctx, eTagContextKey{}, eTag)
+}
+
+// transportHeaders sends the etag of the context with conditional requests and records the rate limit
 type transportHeaders struct {
-	eTag      string
-	transport *http.Transport
+	transport http.RoundTripper
 }
 
 func (t *transportHeaders) RoundTrip(req *http.Request) (*http.Response, error) {
 
-	if t.eTag != "" {
-		req.Header.Set("If-None-Match", t.eTag)
+	if eTag, _ := req.Context().Value(eTagContextKey{}).(string); eTag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", eTag)
 	}
 
-	return http.DefaultTransport.RoundTrip(req)
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil {
+		rateLimit, _ := req.Context().Value(rateLimitContextKey{}).(*RateLimit)
+		recordRateLimit(rateLimit, resp.Header)
+	}
+	return resp, err
+}
+
+// githubRateLimitError converts the rate limit errors of the github client
+func githubRateLimitError(err error) error {
+	var rateLimitErr *githubClient.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return &RateLimitError{RateLimit: RateLimit{Limit: rateLimitErr.Rate.Limit, Remaining: rateLimitErr.Rate.Remaining, Reset: rateLimitErr.Rate.Reset.Time}, Err: err}
+	}
+	var abuseRateLimitErr *githubClient.AbuseRateLimitError
+	if errors.As(err, &abuseRateLimitErr) {
+		// secondary rate limits without Retry-After are retried after a minute, as recommended by github
+		retryAfter := time.Minute
+		if abuseRateLimitErr.RetryAfter != nil {
+			retryAfter = *abuseRateLimitErr.RetryAfter
+		}
+		return &RateLimitError{RateLimit: RateLimit{Reset: time.Now().Add(retryAfter)}, Err: err}
+	}
+	return err
 }
@@ -0,0 +1,249 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newGithubPagesServer serves a github enterprise api with the given number of open pull requests, split into pages
+func newGithubPagesServer(t *testing.T, total int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/jquad/microservice/pulls" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		first := (page - 1) * perPage
+		last := first + perPage
+		if last >= total {
+			last = total
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d&per_page=%d>; rel="next"`, server.URL, r.URL.Path, page+1, perPage))
+		}
+		body := "["
+		for i := first; i < last; i++ {
+			if i > first {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"number": %d, "head": {"ref": "feature-%d", "sha": "%040d"}}`, i+1, i+1, i+1)
+		}
+		body += "]"
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	return server
+}
+
+func TestGithubPollerPollAllPages(t *testing.T) {
+	server := newGithubPagesServer(t, 250)
+	defer server.Close()
+
+	poller := NewGithubPoller(server.URL, "", TransportOptions{}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 250 {
+		t.Fatalf("expected 250 branches, got %d", branches.GetSize())
+	}
+	if branches.Branches[249].Name != "feature-250" {
+		t.Errorf("unexpected last branch %s", branches.Branches[249].Name)
+	}
+}
+
+func TestGithubPollerNotModified(t *testing.T) {
+	// weak etags may contain slashes
+	const eTag = `W/"a1b2/c3d4"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == eTag {
+			w.Header().Set("ETag", eTag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v3/repos/jquad/microservice/pulls/1" {
+			w.Write([]byte(`{"number": 1, "state": "open"}`))
+			return
+		}
+		w.Header().Set("ETag", eTag)
+		w.Write([]byte(`[{"number": 1, "head": {"ref": "feature-a", "sha": "1111111111111111111111111111111111111111"}, "base": {"ref": "main"}}]`))
+	}))
+	defer server.Close()
+
+	ok := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.GITHUB_PROVIDER_NAME, "200"))
+	notModified := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.GITHUB_PROVIDER_NAME, "304"))
+
+	poller := NewGithubPoller(server.URL+"/", "", TransportOptions{}, "jquad", "microservice")
+	branches, returnedETag, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 1 || returnedETag != eTag {
+		t.Fatalf("expected 1 branch with the etag %s, got %d branches and etag %s", eTag, branches.GetSize(), returnedETag)
+	}
+
+	branches, returnedETag, err = poller.Poll(context.Background(), "main", returnedETag)
+	if err != nil {
+		t.Fatalf("expected 304 Not Modified not to be an error, got %v", err)
+	}
+	if !branches.NotModified || branches.GetSize() != 0 || returnedETag != eTag {
+		t.Errorf("expected no branches with the same etag, got %d branches and etag %s", branches.GetSize(), returnedETag)
+	}
+
+	if delta := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.GITHUB_PROVIDER_NAME, "200")) - ok; delta != 1 {
+		t.Errorf("expected 1 request with 200, got %v", delta)
+	}
+	if delta := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.GITHUB_PROVIDER_NAME, "304")) - notModified; delta != 1 {
+		t.Errorf("expected 1 request with 304, got %v", delta)
+	}
+
+	// only the listings are counted
+	if _, _, err := poller.GetState(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if delta := testutil.ToFloat64(gitRequests.WithLabelValues(pullrequestv1alpha1.GITHUB_PROVIDER_NAME, "200")) - ok; delta != 1 {
+		t.Errorf("expected the state lookup not to be counted, got %v requests with 200", delta)
+	}
+}
+
+func TestGithubPollerNotModifiedPages(t *testing.T) {
+	const eTag = `"a1b2c3"`
+	pages := newGithubPagesServer(t, 150)
+	defer pages.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first page does not change, a pull request is only added on the second page
+		if r.Header.Get("If-None-Match") == eTag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", eTag)
+		pages.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	poller := NewGithubPoller(server.URL, "", TransportOptions{}, "jquad", "microservice")
+	branches, returnedETag, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 150 || returnedETag != "" {
+		t.Fatalf("expected 150 branches without an etag, got %d branches and etag %s", branches.GetSize(), returnedETag)
+	}
+
+	branches, _, err = poller.Poll(context.Background(), "main", returnedETag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.NotModified || branches.GetSize() != 150 {
+		t.Errorf("expected all pages to be listed again, got %d branches", branches.GetSize())
+	}
+}
+
+func TestGithubPollerNoResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// the connection is refused
+	server.Close()
+
+	poller := NewGithubPoller(server.URL+"/", "", TransportOptions{}, "jquad", "microservice")
+	if _, _, err := poller.Poll(context.Background(), "main", `"etag"`); err == nil {
+		t.Error("expected an error without a response")
+	}
+}
+
+func TestGithubPollerForks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/jquad/microservice/pulls":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[
+				{"number": 2, "user": {"login": "contributor"}, "head": {"ref": "feature-b", "repo": {"full_name": "contributor/microservice"}}, "base": {"ref": "main", "repo": {"full_name": "jquad/microservice"}}},
+				{"number": 1, "user": {"login": "maintainer"}, "head": {"ref": "feature-a", "repo": {"full_name": "jquad/microservice"}}, "base": {"ref": "main", "repo": {"full_name": "jquad/microservice"}}}
+			]`))
+		case "/api/v3/orgs/jquad/members/maintainer":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v3/orgs/jquad/teams/reviewers/memberships/maintainer":
+			w.Write([]byte(`{"state": "pending"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	poller := NewGithubPoller(server.URL+"/", "", TransportOptions{}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || !branches.Branches[0].Fork || branches.Branches[0].Author != "contributor" || branches.Branches[1].Fork {
+		t.Errorf("expected the first pull request to be opened from a fork, got %+v", branches.Branches)
+	}
+
+	for _, test := range []struct {
+		user   string
+		member bool
+	}{{"maintainer", true}, {"contributor", false}} {
+		member, err := poller.IsOrganizationMember(context.Background(), "jquad", test.user)
+		if err != nil || member != test.member {
+			t.Errorf("expected the organization membership of %s to be %t, got %t: %v", test.user, test.member, member, err)
+		}
+		// pending memberships are not trusted
+		member, err = poller.IsTeamMember(context.Background(), "jquad", "reviewers", test.user)
+		if err != nil || member {
+			t.Errorf("expected %s not to be an active team member, got %t: %v", test.user, member, err)
+		}
+	}
+}
+
+func TestGithubPollerListComments(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/jquad/microservice/issues/2/comments" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") != "2" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2>; rel="next"`, server.URL, r.URL.Path))
+			w.Write([]byte(`[{"user": {"login": "contributor"}, "body": "Please review", "created_at": "2022-11-01T12:00:00Z"}]`))
+			return
+		}
+		w.Write([]byte(`[{"user": {"login": "maintainer"}, "body": "/ok-to-test", "created_at": "2022-11-01T13:00:00Z"}]`))
+	}))
+	defer server.Close()
+
+	poller := NewGithubPoller(server.URL+"/", "", TransportOptions{}, "jquad", "microservice")
+	comments, err := poller.ListComments(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[1].Author != "maintainer" || comments[1].Body != "/ok-to-test" || comments[1].Created.Hour() != 13 {
+		t.Errorf("expected the comments of both pages, got %+v", comments)
+	}
+}
+
+func TestGithubPollerGetCommitTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	// the commit date is set by the author, the controller falls back to the time it first saw the commit
+	poller := NewGithubPoller(server.URL+"/", "", TransportOptions{}, "jquad", "microservice")
+	pushed, err := poller.GetCommitTime(context.Background(), "2", "3333333333333333333333333333333333333333")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed.IsZero() {
+		t.Errorf("expected the zero time, got %s", pushed)
+	}
+}
@@ -0,0 +1,158 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+const (
+	gitlabApiPath     = "/api/v4"
+	gitlabPageSize    = "100"
+	gitlabTokenHeader = "PRIVATE-TOKEN"
+	gitlabNextPage    = "X-Next-Page"
+)
+
+type GitlabPoller struct {
+	Endpoint    string
+	AccessToken string
+	Transport   TransportOptions
+	Project     string
+}
+
+type gitlabMergeRequest struct {
+	IID             int      `json:"iid"`
+	State           string   `json:"state"`
+	SourceBranch    string   `json:"source_branch"`
+	TargetBranch    string   `json:"target_branch"`
+	SHA             string   `json:"sha"`
+	MergeCommitSHA  string   `json:"merge_commit_sha"`
+	SquashCommitSHA string   `json:"squash_commit_sha"`
+	Labels          []string `json:"labels"`
+	Draft           bool     `json:"draft"`
+}
+
+func NewGitlabPoller(endpoint string, accessToken string, transportOptions TransportOptions, project string) *GitlabPoller {
+	return &GitlabPoller{
+		Endpoint:    endpoint,
+		AccessToken: accessToken,
+		Transport:   transportOptions,
+		Project:     project,
+	}
+}
+
+func (gitlabPoller GitlabPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	httpClient, err := newHTTPClient(gitlabPoller.Transport)
+	if err != nil {
+		return pullrequestv1alpha1.Branches{}, "", err
+	}
+
+	var branches pullrequestv1alpha1.Branches
+
+	baseUrl, err := url.Parse(gitlabPoller.mergeRequestsUrl())
+	if err != nil {
+		return branches, "", err
+	}
+
+	var sourceBranches []pullrequestv1alpha1.Branch
+	page := "1"
+	for page != "" {
+		query := url.Values{}
+		query.Set("state", "opened")
+		// without a branch, the merge requests of all target branches are listed
+		if len(branch) > 0 {
+			query.Set("target_branch", trimBranchRef(branch))
+		}
+		query.Set("per_page", gitlabPageSize)
+		query.Set("page", page)
+		baseUrl.RawQuery = query.Encode()
+
+		req, err := gitlabPoller.newRequest(ctx, baseUrl.String())
+		if err != nil {
+			return branches, "", err
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return branches, "", err
+		}
+		var mrList []json.RawMessage
+		err = decodeJSONResponse(resp, &mrList)
+		if err != nil {
+			return branches, "", err
+		}
+
+		for i := 0; i < len(mrList); i++ {
+			var mr gitlabMergeRequest
+			if err := json.Unmarshal(mrList[i], &mr); err != nil {
+				return branches, "", err
+			}
+			var tempBranch pullrequestv1alpha1.Branch
+			tempBranch.ID = strconv.Itoa(mr.IID)
+			tempBranch.Name = mr.SourceBranch
+			tempBranch.Commit = mr.SHA
+			tempBranch.TargetBranch = mr.TargetBranch
+			tempBranch.Labels = mr.Labels
+			tempBranch.Draft = mr.Draft
+			tempBranch.Details = string(mrList[i])
+			sourceBranches = append(sourceBranches, tempBranch)
+		}
+
+		page = resp.Header.Get(gitlabNextPage)
+	}
+
+	branches.Branches = sourceBranches
+
+	return branches, "", nil
+}
+
+func (gitlabPoller GitlabPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	httpClient, err := newHTTPClient(gitlabPoller.Transport)
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := gitlabPoller.newRequest(ctx, gitlabPoller.mergeRequestsUrl()+"/"+url.PathEscape(id))
+	if err != nil {
+		return "", "", err
+	}
+	var mr gitlabMergeRequest
+	if err := getJSON(httpClient, req, &mr); err != nil {
+		return "", "", err
+	}
+
+	switch mr.State {
+	case "opened":
+		return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+	case "merged":
+		if len(mr.MergeCommitSHA) == 0 {
+			// fast-forward merges have no merge commit, the squash commit is reported if the merge request was squashed
+			// and no commit otherwise
+			return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, mr.SquashCommitSHA, nil
+		}
+		return pullrequestv1alpha1.PULLREQUEST_STATE_MERGED, mr.MergeCommitSHA, nil
+	}
+	return pullrequestv1alpha1.PULLREQUEST_STATE_CLOSED, "", nil
+}
+
+// mergeRequestsUrl returns the url of the merge requests of the project. The project can either be a numeric ID or
+// a path, which has to be encoded as a single path segment.
+func (gitlabPoller GitlabPoller) mergeRequestsUrl() string {
+	return strings.TrimSuffix(gitlabPoller.Endpoint, "/") + gitlabApiPath + "/projects/" + url.PathEscape(gitlabPoller.Project) + "/merge_requests"
+}
+
+func (gitlabPoller GitlabPoller) newRequest(ctx context.Context, requestUrl string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(gitlabPoller.AccessToken) > 0 {
+		req.Header.Set(gitlabTokenHeader, strings.TrimSuffix(gitlabPoller.AccessToken, "\n"))
+	}
+	return req, nil
+}
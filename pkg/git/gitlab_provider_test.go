@@ -0,0 +1,129 @@
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitlabPollerPollNextPage(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/jquad%2Fmicroservice/merge_requests" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		if r.URL.Query().Get("state") != "opened" || r.URL.Query().Get("target_branch") != "main" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("unexpected PRIVATE-TOKEN header %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		w.Header().Set("Content-Type", "application/json")
+		// the pages are not numbered consecutively, the last page is full and has an empty X-Next-Page header
+		switch page {
+		case "1":
+			w.Header().Set("X-Next-Page", "3")
+			w.Write([]byte(`[{"iid": 1, "state": "opened", "source_branch": "feature-1", "target_branch": "main", "sha": "1111111111111111111111111111111111111111", "labels": ["ci/run"], "draft": true}]`))
+		case "3":
+			w.Header().Set("X-Next-Page", "")
+			w.Write([]byte(`[{"iid": 2, "state": "opened", "source_branch": "feature-2", "target_branch": "main", "sha": "2222222222222222222222222222222222222222"}]`))
+		default:
+			t.Errorf("unexpected page %s", page)
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	poller := NewGitlabPoller(server.URL, "secret\n", TransportOptions{}, "jquad/microservice")
+	branches, _, err := poller.Poll(context.Background(), "refs/heads/main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || pages[1] != "3" {
+		t.Errorf("expected the pages of the X-Next-Page header, got %v", pages)
+	}
+	if branches.GetSize() != 2 {
+		t.Fatalf("expected the merge requests of both pages, got %d", branches.GetSize())
+	}
+	first := branches.Branches[0]
+	if first.ID != "1" || first.Name != "feature-1" || first.Commit != "1111111111111111111111111111111111111111" || first.TargetBranch != "main" || !first.Draft || len(first.Labels) != 1 || first.Details == "" {
+		t.Errorf("unexpected branch %+v", first)
+	}
+}
+
+func TestGitlabPollerPollNumericProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/gitlab/api/v4/projects/42/merge_requests" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		// without a branch, the merge requests of all target branches are listed
+		if _, ok := r.URL.Query()["target_branch"]; ok {
+			t.Errorf("unexpected target_branch %s", r.URL.Query().Get("target_branch"))
+		}
+		w.Write([]byte(`[{"iid": 1, "source_branch": "feature-1", "target_branch": "develop"}]`))
+	}))
+	defer server.Close()
+
+	poller := NewGitlabPoller(server.URL+"/gitlab/", "", TransportOptions{}, "42")
+	branches, _, err := poller.Poll(context.Background(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 1 || branches.Branches[0].TargetBranch != "develop" {
+		t.Errorf("expected the merge request to develop, got %+v", branches.Branches)
+	}
+}
+
+func TestGitlabPollerPollError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	poller := NewGitlabPoller(server.URL, "wrong", TransportOptions{}, "jquad/microservice")
+	_, _, err := poller.Poll(context.Background(), "main", "")
+	if statusCode, ok := StatusCode(err); !ok || statusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestGitlabPollerGetState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/jquad%2Fmicroservice/merge_requests/1":
+			w.Write([]byte(`{"iid": 1, "state": "merged", "merge_commit_sha": "4444444444444444444444444444444444444444"}`))
+		case "/api/v4/projects/jquad%2Fmicroservice/merge_requests/2":
+			w.Write([]byte(`{"iid": 2, "state": "merged", "merge_commit_sha": null, "squash_commit_sha": "5555555555555555555555555555555555555555"}`))
+		case "/api/v4/projects/jquad%2Fmicroservice/merge_requests/3":
+			w.Write([]byte(`{"iid": 3, "state": "closed"}`))
+		case "/api/v4/projects/jquad%2Fmicroservice/merge_requests/4":
+			w.Write([]byte(`{"iid": 4, "state": "opened"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	poller := NewGitlabPoller(server.URL, "", TransportOptions{}, "jquad/microservice")
+	for id, expected := range map[string][2]string{
+		"1": {"merged", "4444444444444444444444444444444444444444"},
+		"2": {"merged", "5555555555555555555555555555555555555555"},
+		"3": {"closed", ""},
+		"4": {"open", ""},
+	} {
+		state, mergeCommit, err := poller.GetState(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != expected[0] || mergeCommit != expected[1] {
+			t.Errorf("%s: expected %s with merge commit %q, got %s with %q", id, expected[0], expected[1], state, mergeCommit)
+		}
+	}
+
+	if _, _, err := poller.GetState(context.Background(), "5"); err == nil {
+		t.Error("expected an error for an unknown merge request")
+	}
+}
@@ -0,0 +1,65 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// newHTTPClient creates a client with the cached transport of the options, so that the TLS and proxy settings are
+// not shared between pollers with different settings
+func newHTTPClient(transportOptions TransportOptions) (*http.Client, error) {
+	transport, err := getTransport(transportOptions)
+	if err != nil {
+		return nil, err
+	}
+	var roundTripper http.RoundTripper = transport
+	if transportOptions.RateLimit != nil {
+		roundTripper = &rateLimitTransport{transport: transport, rateLimit: transportOptions.RateLimit}
+	}
+	return &http.Client{Transport: roundTripper, Timeout: transportOptions.timeout()}, nil
+}
+
+// getJSON sends the request and decodes the JSON response into v
+func getJSON(httpClient *http.Client, req *http.Request, v interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeJSONResponse(resp, v)
+}
+
+// nextLink returns the url of the next page of the Link header, or an empty string on the last page
+func nextLink(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// trimBranchRef returns the short branch name, e.g. main for refs/heads/main
+func trimBranchRef(branch string) string {
+	return strings.TrimPrefix(branch, "refs/heads/")
+}
+
+// decodeJSONResponse decodes the body of a successful response into v and closes the body
+func decodeJSONResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// limitReached returns true if an upper bound for the number of pull requests is set and count reached it
+func limitReached(count int, maxPullRequests int) bool {
+	return maxPullRequests > 0 && count >= maxPullRequests
+}
@@ -0,0 +1,29 @@
+package v1alpha1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// gitRequests counts the listings of the pollers with conditional requests, 304 if the pull requests did not change.
+// Further pages, states, comments and memberships are not counted.
+var gitRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "pullrequest_operator_git_requests_total",
+	Help: "Number of listings of pull requests by the git provider by status code, 304 for listings which did not change",
+}, []string{"provider", "code"})
+
+// recordListing counts a listing, which was not modified or returned the pull requests
+func recordListing(provider string, notModified bool) {
+	code := http.StatusOK
+	if notModified {
+		code = http.StatusNotModified
+	}
+	gitRequests.WithLabelValues(provider, strconv.Itoa(code)).Inc()
+}
+
+func init() {
+	metrics.Registry.MustRegister(gitRequests)
+}
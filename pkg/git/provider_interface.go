@@ -1,9 +1,46 @@
 package v1alpha1
 
 import (
+	"context"
+	"time"
+
 	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
 )
 
 type PullrequestPoller interface {
-	Poll(branch string, etag string) (pullrequestv1alpha1.Branches, string, error)
+	// Poll lists the open pull requests to the branch, or to all branches if the branch is empty. The requests to the
+	// git provider are aborted when the context is cancelled. If the pull requests did not change since the poll which
+	// returned the etag, the branches are marked as NotModified and returned together with the same etag. Pollers may
+	// omit the pull requests of a listing, which was not modified.
+	Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error)
+
+	// GetState looks up a single pull request by its ID and returns its state, i.e. open, merged, declined or
+	// closed, and the merge commit if the pull request was merged and the git provider reports it
+	GetState(ctx context.Context, id string) (string, string, error)
+}
+
+// MembershipChecker looks up the memberships of users, e.g. of the authors of pull requests from forks
+type MembershipChecker interface {
+	IsOrganizationMember(ctx context.Context, organization string, user string) (bool, error)
+
+	// IsTeamMember returns true if the user is an active member of the team, which is given by its slug
+	IsTeamMember(ctx context.Context, organization string, team string, user string) (bool, error)
+}
+
+// Comment on a pull request
+type Comment struct {
+	// User name of the author, i.e. the login of Github or the user slug of Bitbucket Server
+	Author  string
+	Body    string
+	Created time.Time
+}
+
+// CommentLister lists the comments of pull requests, e.g. to find the approvals for testing
+type CommentLister interface {
+	// ListComments returns the comments of the pull request given by its ID, including replies
+	ListComments(ctx context.Context, id string) ([]Comment, error)
+
+	// GetCommitTime returns the time the commit was pushed to the pull request given by its ID, or the zero time if
+	// the git provider does not report the pushes
+	GetCommitTime(ctx context.Context, id string, commit string) (time.Time, error)
 }
@@ -0,0 +1,118 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the request quota reported by the git provider
+type RateLimit struct {
+	// Limit is the number of requests per window, or 0 if unknown
+	Limit int
+	// Remaining is the number of requests left in the current window
+	Remaining int
+	// Reset is the time the quota is replenished, or when a rejected request may be retried
+	Reset time.Time
+}
+
+// Known returns true if the git provider reported a rate limit
+func (rateLimit RateLimit) Known() bool {
+	return rateLimit.Limit > 0 || !rateLimit.Reset.IsZero()
+}
+
+// Exhausted returns true if no requests are left before the reset
+func (rateLimit RateLimit) Exhausted(now time.Time) bool {
+	return rateLimit.Known() && rateLimit.Remaining <= 0 && rateLimit.Reset.After(now)
+}
+
+// RateLimitError is returned if the git provider rejected a request because of its rate limit
+type RateLimitError struct {
+	RateLimit RateLimit
+	Err       error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s: %v", e.RateLimit.Reset.Format(time.RFC3339), e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// parseRateLimit reads the X-RateLimit-* headers of GitHub and Gitea, the RateLimit-* headers of GitLab and the
+// Retry-After header, which is sent by Bitbucket and GitHub's secondary rate limits
+func parseRateLimit(header http.Header, now time.Time) (RateLimit, bool) {
+	var rateLimit RateLimit
+	found := false
+
+	if limit, ok := headerInt(header, "X-RateLimit-Limit", "RateLimit-Limit"); ok {
+		rateLimit.Limit = limit
+		found = true
+	}
+	if remaining, ok := headerInt(header, "X-RateLimit-Remaining", "RateLimit-Remaining"); ok {
+		rateLimit.Remaining = remaining
+		found = true
+	}
+	if reset, ok := headerInt(header, "X-RateLimit-Reset", "RateLimit-Reset"); ok {
+		rateLimit.Reset = time.Unix(int64(reset), 0)
+		found = true
+	}
+
+	// the delay is either in seconds or a http date
+	if retryAfter := header.Get("Retry-After"); len(retryAfter) > 0 {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			rateLimit.Reset = now.Add(time.Duration(seconds) * time.Second)
+			found = true
+		} else if date, err := http.ParseTime(retryAfter); err == nil {
+			rateLimit.Reset = date
+			found = true
+		}
+		rateLimit.Remaining = 0
+	}
+
+	return rateLimit, found
+}
+
+func headerInt(header http.Header, keys ...string) (int, bool) {
+	for _, key := range keys {
+		if value := header.Get(key); len(value) > 0 {
+			if parsed, err := strconv.Atoi(value); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// rateLimitTransport records the rate limit of each response
+type rateLimitTransport struct {
+	transport http.RoundTripper
+	rateLimit *RateLimit
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err == nil {
+		recordRateLimit(t.rateLimit, resp.Header)
+	}
+	return resp, err
+}
+
+func recordRateLimit(rateLimit *RateLimit, header http.Header) {
+	if rateLimit == nil {
+		return
+	}
+	if parsed, ok := parseRateLimit(header, time.Now()); ok {
+		*rateLimit = parsed
+	}
+}
+
+type rateLimitContextKey struct{}
+
+// withRateLimit records the rate limit of the responses to the requests of the context, it is used for shared clients
+func withRateLimit(ctx context.Context, rateLimit *RateLimit) context.Context {
+	return context.WithValue(ctx, rateLimitContextKey{}, rateLimit)
+}
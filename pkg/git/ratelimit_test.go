@@ -0,0 +1,97 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2022, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", "4711")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+	rateLimit, ok := parseRateLimit(header, now)
+	if !ok || rateLimit.Limit != 5000 || rateLimit.Remaining != 4711 || !rateLimit.Reset.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected rate limit %+v", rateLimit)
+	}
+	if rateLimit.Exhausted(now) {
+		t.Error("expected the rate limit not to be exhausted")
+	}
+
+	header = http.Header{}
+	header.Set("Retry-After", "120")
+	rateLimit, ok = parseRateLimit(header, now)
+	if !ok || rateLimit.Remaining != 0 || !rateLimit.Reset.Equal(now.Add(2*time.Minute)) || !rateLimit.Exhausted(now) {
+		t.Errorf("unexpected rate limit for a delay in seconds %+v", rateLimit)
+	}
+
+	header = http.Header{}
+	header.Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+	rateLimit, ok = parseRateLimit(header, now)
+	if !ok || !rateLimit.Reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("unexpected rate limit for a http date %+v", rateLimit)
+	}
+
+	if _, ok := parseRateLimit(http.Header{}, now); ok {
+		t.Error("expected no rate limit without headers")
+	}
+}
+
+func TestGiteaPollerRateLimited(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	rateLimit := &RateLimit{}
+	transportOptions := TransportOptions{RateLimit: rateLimit}
+	poller := NewSharedPoller("Gitea/"+server.URL+"/jquad/ratelimited", "", transportOptions, NewGiteaPoller(server.URL, "", transportOptions, "jquad", "ratelimited"), 0, 0)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := poller.Poll(context.Background(), "main", "")
+		var rateLimitErr *RateLimitError
+		if !errors.As(err, &rateLimitErr) {
+			t.Fatalf("expected a rate limit error, got %v", err)
+		}
+		if rateLimitErr.RateLimit.Limit != 60 || !rateLimitErr.RateLimit.Reset.Equal(reset) {
+			t.Errorf("unexpected rate limit %+v", rateLimitErr.RateLimit)
+		}
+		if rateLimit.Remaining != 0 || !rateLimit.Reset.Equal(reset) {
+			t.Errorf("expected the rate limit to be reported, got %+v", *rateLimit)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected no request before the rate limit resets, got %d requests", requests)
+	}
+}
+
+func TestCheckResponseForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var rateLimitErr *RateLimitError
+	if err := checkResponse(resp); err == nil || errors.As(err, &rateLimitErr) {
+		t.Errorf("expected a permission error instead of a rate limit error, got %v", err)
+	}
+}
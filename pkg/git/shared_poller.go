@@ -0,0 +1,230 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+// open pull requests of a repository, which were listed for all target branches
+type repositoryPullRequests struct {
+	// serializes the fetches of the repository, so that concurrent reconciliations list the pull requests once
+	sync.Mutex
+	branches pullrequestv1alpha1.Branches
+	eTag     string
+	fetched  time.Time
+	// rate limit reported with the last listing
+	rateLimit RateLimit
+}
+
+var repositories = struct {
+	sync.Mutex
+	cache map[string]*repositoryPullRequests
+}{cache: map[string]*repositoryPullRequests{}}
+
+// SharedPoller lists the open pull requests of a repository for all target branches once per interval and shares them
+// with all PullRequest objects watching the repository. The pull requests are filtered by the target branch locally.
+type SharedPoller struct {
+	// RepositoryKey identifies the repository, see RepositoryKey
+	RepositoryKey string
+	// Credentials distinguish the listings of users with different permissions on the same repository
+	Credentials string
+	// Transport distinguishes the listings with different TLS settings, e.g. a CA bundle
+	Transport       TransportOptions
+	Poller          PullrequestPoller
+	Interval        time.Duration
+	MaxPullRequests int
+	// Filter selects the pull requests of the object before they are bounded by MaxPullRequests, all pull requests
+	// to the target branch are selected if not set
+	Filter func(pullrequestv1alpha1.Branch) bool
+}
+
+// NewSharedPoller wraps a poller, which lists the pull requests of all target branches if it is polled without a branch.
+// The pollers list all open pull requests, the upper bound maxPullRequests is applied to the pull requests of each
+// object. The pull requests of the previous listing are kept, as long as the poller reports them as not modified.
+func NewSharedPoller(repositoryKey string, credentials string, transportOptions TransportOptions, poller PullrequestPoller, interval time.Duration, maxPullRequests int) *SharedPoller {
+	return &SharedPoller{
+		RepositoryKey:   repositoryKey,
+		Credentials:     credentials,
+		Transport:       transportOptions,
+		Poller:          poller,
+		Interval:        interval,
+		MaxPullRequests: maxPullRequests,
+	}
+}
+
+// RepositoryKey identifies the repository of a git provider, independent of the target branch
+func RepositoryKey(gitProvider pullrequestv1alpha1.GitProvider) string {
+	switch gitProvider.Provider {
+	case pullrequestv1alpha1.AZUREDEVOPS_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.AzureDevOps.Url, gitProvider.AzureDevOps.Organization, gitProvider.AzureDevOps.Project, gitProvider.AzureDevOps.Repository}, "/")
+	case pullrequestv1alpha1.BITBUCKET_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.Bitbucket.RestEndpoint, gitProvider.Bitbucket.Project, gitProvider.Bitbucket.Repository}, "/")
+	case pullrequestv1alpha1.BITBUCKETCLOUD_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.BitbucketCloud.Url, gitProvider.BitbucketCloud.Workspace, gitProvider.BitbucketCloud.Repository}, "/")
+	case pullrequestv1alpha1.GERRIT_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.Gerrit.Url, gitProvider.Gerrit.Project}, "/")
+	case pullrequestv1alpha1.GITHUB_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.Github.Url, gitProvider.Github.Owner, gitProvider.Github.Repository}, "/")
+	case pullrequestv1alpha1.GITLAB_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.Gitlab.Url, gitProvider.Gitlab.Project}, "/")
+	case pullrequestv1alpha1.GITEA_PROVIDER_NAME:
+		return strings.Join([]string{gitProvider.Provider, gitProvider.Gitea.Url, gitProvider.Gitea.Owner, gitProvider.Gitea.Repository}, "/")
+	}
+	return gitProvider.Provider
+}
+
+// ExpireRepository forces the next poll of the repository to list the pull requests again, e.g. after a webhook
+func ExpireRepository(repositoryKey string) {
+	repositories.Lock()
+	var expired []*repositoryPullRequests
+	for key, repository := range repositories.cache {
+		if strings.HasPrefix(key, repositoryKey+"/") {
+			expired = append(expired, repository)
+		}
+	}
+	repositories.Unlock()
+
+	for _, repository := range expired {
+		repository.Lock()
+		repository.fetched = time.Time{}
+		repository.Unlock()
+	}
+}
+
+func getRepository(key string) *repositoryPullRequests {
+	repositories.Lock()
+	defer repositories.Unlock()
+
+	// repositories which are no longer watched are removed, the rate limit is kept until it resets
+	now := time.Now()
+	for cachedKey, cached := range repositories.cache {
+		if cached.TryLock() {
+			if now.Sub(cached.fetched) > transportIdleTimeout && !cached.rateLimit.Exhausted(now) {
+				delete(repositories.cache, cachedKey)
+			}
+			cached.Unlock()
+		}
+	}
+
+	repository, ok := repositories.cache[key]
+	if !ok {
+		repository = &repositoryPullRequests{}
+		repositories.cache[key] = repository
+	}
+	return repository
+}
+
+func (sharedPoller SharedPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	var branches pullrequestv1alpha1.Branches
+
+	transportHash, err := sharedPoller.Transport.hash()
+	if err != nil {
+		return branches, "", err
+	}
+	credentialsHash := sha256.Sum256([]byte(sharedPoller.Credentials))
+	repository := getRepository(sharedPoller.RepositoryKey + "/" + hex.EncodeToString(credentialsHash[:]) + "/" + transportHash)
+	repository.Lock()
+	defer repository.Unlock()
+
+	if time.Since(repository.fetched) >= sharedPoller.Interval {
+		// the pull requests are not listed before the rate limit resets, as the request would be rejected anyway
+		if repository.rateLimit.Exhausted(time.Now()) {
+			sharedPoller.recordRateLimit(repository.rateLimit)
+			return branches, "", &RateLimitError{RateLimit: repository.rateLimit, Err: errors.New("no requests left")}
+		}
+		if sharedPoller.Transport.RateLimit != nil {
+			*sharedPoller.Transport.RateLimit = RateLimit{}
+		}
+		allBranches, eTag, err := sharedPoller.Poller.Poll(ctx, "", repository.eTag)
+		if sharedPoller.Transport.RateLimit != nil {
+			repository.rateLimit = *sharedPoller.Transport.RateLimit
+		}
+		if err != nil {
+			var rateLimitErr *RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				repository.rateLimit = rateLimitErr.RateLimit
+				sharedPoller.recordRateLimit(repository.rateLimit)
+			} else if repository.rateLimit.Exhausted(time.Now()) {
+				// some clients do not report the status code, e.g. the one of bitbucket server
+				err = &RateLimitError{RateLimit: repository.rateLimit, Err: err}
+			}
+			return branches, "", err
+		}
+		if !allBranches.NotModified {
+			repository.branches = allBranches
+		}
+		repository.eTag = eTag
+		repository.fetched = time.Now()
+	}
+	sharedPoller.recordRateLimit(repository.rateLimit)
+
+	for _, pr := range repository.branches.Branches {
+		if trimBranchRef(pr.TargetBranch) != trimBranchRef(branch) {
+			continue
+		}
+		if sharedPoller.Filter != nil && !sharedPoller.Filter(pr) {
+			continue
+		}
+		if limitReached(len(branches.Branches), sharedPoller.MaxPullRequests) {
+			// another pull request to the target branch is cut off
+			branches.Truncated = true
+			break
+		}
+		branches.Branches = append(branches.Branches, pr)
+	}
+
+	// the etag of the repository changes with the pull requests of any target branch
+	branches.NotModified = len(etag) > 0 && etag == repository.eTag
+	return branches, repository.eTag, nil
+}
+
+// recordRateLimit reports the rate limit of the repository, also if the pull requests were taken from the cache
+func (sharedPoller SharedPoller) recordRateLimit(rateLimit RateLimit) {
+	if sharedPoller.Transport.RateLimit != nil {
+		*sharedPoller.Transport.RateLimit = rateLimit
+	}
+}
+
+// IsOrganizationMember looks up the membership with the wrapped poller, the memberships are not cached
+func (sharedPoller SharedPoller) IsOrganizationMember(ctx context.Context, organization string, user string) (bool, error) {
+	checker, ok := sharedPoller.Poller.(MembershipChecker)
+	if !ok {
+		return false, errors.New("the git provider does not support organization memberships")
+	}
+	return checker.IsOrganizationMember(ctx, organization, user)
+}
+
+func (sharedPoller SharedPoller) IsTeamMember(ctx context.Context, organization string, team string, user string) (bool, error) {
+	checker, ok := sharedPoller.Poller.(MembershipChecker)
+	if !ok {
+		return false, errors.New("the git provider does not support team memberships")
+	}
+	return checker.IsTeamMember(ctx, organization, team, user)
+}
+
+func (sharedPoller SharedPoller) ListComments(ctx context.Context, id string) ([]Comment, error) {
+	lister, ok := sharedPoller.Poller.(CommentLister)
+	if !ok {
+		return nil, errors.New("the git provider does not support comments")
+	}
+	return lister.ListComments(ctx, id)
+}
+
+func (sharedPoller SharedPoller) GetCommitTime(ctx context.Context, id string, commit string) (time.Time, error) {
+	lister, ok := sharedPoller.Poller.(CommentLister)
+	if !ok {
+		return time.Time{}, errors.New("the git provider does not support comments")
+	}
+	return lister.GetCommitTime(ctx, id, commit)
+}
+
+func (sharedPoller SharedPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	return sharedPoller.Poller.GetState(ctx, id)
+}
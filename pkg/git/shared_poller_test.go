@@ -0,0 +1,157 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+type countingPoller struct {
+	polls    *int
+	branches pullrequestv1alpha1.Branches
+}
+
+func (countingPoller countingPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	*countingPoller.polls++
+	return countingPoller.branches, "", nil
+}
+
+func (countingPoller countingPoller) GetState(ctx context.Context, id string) (string, string, error) {
+	return pullrequestv1alpha1.PULLREQUEST_STATE_OPEN, "", nil
+}
+
+func newCountingPoller(polls *int) countingPoller {
+	return countingPoller{polls: polls, branches: pullrequestv1alpha1.Branches{Branches: []pullrequestv1alpha1.Branch{
+		{ID: "1", Name: "feature-a", TargetBranch: "main"},
+		{ID: "2", Name: "feature-b", TargetBranch: "develop"},
+		{ID: "3", Name: "feature-c", TargetBranch: "main"},
+	}}}
+}
+
+func TestSharedPollerListsRepositoryOnce(t *testing.T) {
+	polls := 0
+	poller := newCountingPoller(&polls)
+	main := NewSharedPoller("Github/https://ghe.jquad.rocks/jquad/shared", "token", TransportOptions{}, poller, time.Minute, 0)
+	develop := NewSharedPoller("Github/https://ghe.jquad.rocks/jquad/shared", "token", TransportOptions{}, poller, time.Minute, 0)
+
+	mainBranches, _, err := main.Poll(context.Background(), "refs/heads/main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	developBranches, _, err := develop.Poll(context.Background(), "develop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if polls != 1 {
+		t.Errorf("expected the repository to be listed once, got %d polls", polls)
+	}
+	if mainBranches.GetSize() != 2 || mainBranches.Branches[0].Name != "feature-a" || mainBranches.Branches[1].Name != "feature-c" {
+		t.Errorf("unexpected branches for main %+v", mainBranches.Branches)
+	}
+	if developBranches.GetSize() != 1 || developBranches.Branches[0].Name != "feature-b" {
+		t.Errorf("unexpected branches for develop %+v", developBranches.Branches)
+	}
+
+	ExpireRepository("Github/https://ghe.jquad.rocks/jquad/shared")
+	if _, _, err := main.Poll(context.Background(), "main", ""); err != nil {
+		t.Fatal(err)
+	}
+	if polls != 2 {
+		t.Errorf("expected the expired repository to be listed again, got %d polls", polls)
+	}
+}
+
+func TestSharedPollerSeparatesCredentials(t *testing.T) {
+	polls := 0
+	poller := newCountingPoller(&polls)
+	for _, credentials := range []string{"token", "other-token"} {
+		sharedPoller := NewSharedPoller("Github/https://ghe.jquad.rocks/jquad/credentials", credentials, TransportOptions{}, poller, time.Minute, 0)
+		if _, _, err := sharedPoller.Poll(context.Background(), "main", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if polls != 2 {
+		t.Errorf("expected a separate listing for each credentials, got %d polls", polls)
+	}
+}
+
+func TestSharedPollerMaxPullRequests(t *testing.T) {
+	polls := 0
+	sharedPoller := NewSharedPoller("Github/https://ghe.jquad.rocks/jquad/max", "token", TransportOptions{}, newCountingPoller(&polls), time.Minute, 1)
+	branches, _, err := sharedPoller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 1 || branches.Branches[0].Name != "feature-a" || !branches.Truncated {
+		t.Errorf("expected the pull requests of the target branch to be bounded, got %+v", branches)
+	}
+
+	// the bound equals the number of pull requests to the target branch
+	sharedPoller.MaxPullRequests = 2
+	branches, _, err = sharedPoller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || branches.Truncated {
+		t.Errorf("expected all pull requests of the target branch without truncation, got %+v", branches)
+	}
+}
+
+func TestSharedPollerFilter(t *testing.T) {
+	polls := 0
+	poller := newCountingPoller(&polls)
+	poller.branches.Branches[0].Labels = []string{"wip"}
+	sharedPoller := NewSharedPoller("Github/https://ghe.jquad.rocks/jquad/filter", "token", TransportOptions{}, poller, time.Minute, 1)
+	sharedPoller.Filter = func(branch pullrequestv1alpha1.Branch) bool {
+		return len(branch.Labels) == 0
+	}
+	branches, _, err := sharedPoller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the filter applies before the bound
+	if branches.GetSize() != 1 || branches.Branches[0].Name != "feature-c" {
+		t.Errorf("expected the filtered pull requests of the target branch, got %+v", branches.Branches)
+	}
+}
+
+// notModifiedPoller reports the listings after the first one as not modified, without pull requests
+type notModifiedPoller struct {
+	countingPoller
+}
+
+func (notModifiedPoller notModifiedPoller) Poll(ctx context.Context, branch string, etag string) (pullrequestv1alpha1.Branches, string, error) {
+	*notModifiedPoller.polls++
+	if *notModifiedPoller.polls > 1 {
+		return pullrequestv1alpha1.Branches{NotModified: true}, etag, nil
+	}
+	return notModifiedPoller.branches, "listing-1", nil
+}
+
+func TestSharedPollerKeepsNotModifiedListing(t *testing.T) {
+	polls := 0
+	poller := notModifiedPoller{newCountingPoller(&polls)}
+	sharedPoller := NewSharedPoller("Github/https://ghe.jquad.rocks/jquad/not-modified", "token", TransportOptions{}, poller, 0, 0)
+
+	branches, eTag, err := sharedPoller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.NotModified || branches.GetSize() != 2 {
+		t.Fatalf("expected 2 modified branches, got %d branches and not modified %t", branches.GetSize(), branches.NotModified)
+	}
+
+	branches, _, err = sharedPoller.Poll(context.Background(), "main", eTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls != 2 {
+		t.Errorf("expected the repository to be listed again, got %d polls", polls)
+	}
+	if !branches.NotModified || branches.GetSize() != 2 {
+		t.Errorf("expected the 2 cached branches to be not modified, got %d branches and not modified %t", branches.GetSize(), branches.NotModified)
+	}
+}
@@ -0,0 +1,45 @@
+package v1alpha1
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// TLSOptions configures the verification of the git provider's certificate and the client certificate for mutual TLS
+type TLSOptions struct {
+	InsecureSkipVerify bool
+	// PEM encoded CA certificates, which are trusted in addition to the system roots
+	CABundle []byte
+	// PEM encoded client certificate and key
+	ClientCert []byte
+	ClientKey  []byte
+}
+
+// TLSConfig builds the tls.Config of the options
+func (tlsOptions TLSOptions) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: tlsOptions.InsecureSkipVerify,
+	}
+
+	if len(tlsOptions.CABundle) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(tlsOptions.CABundle) {
+			return nil, fmt.Errorf("invalid CA bundle: no PEM encoded certificate found")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if len(tlsOptions.ClientCert) > 0 || len(tlsOptions.ClientKey) > 0 {
+		clientCert, err := tls.X509KeyPair(tlsOptions.ClientCert, tlsOptions.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,142 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pullrequestv1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+// newTLSServer serves one open pull request for github enterprise and bitbucket server, if clientCAs is set, a client
+// certificate signed by it is required
+func newTLSServer(t *testing.T, clientCAs *x509.CertPool) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/repos/jquad/microservice/pulls":
+			w.Write([]byte(`[{"number": 1, "head": {"ref": "feature-a", "sha": "1111111111111111111111111111111111111111"}}]`))
+		case "/rest/api/1.0/projects/jquad/repos/microservice/pull-requests":
+			w.Write([]byte(`{"values": [{"id": 1, "fromRef": {"displayId": "feature-a", "latestCommit": "1111111111111111111111111111111111111111"}}], "isLastPage": true}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	if clientCAs != nil {
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	}
+	server.StartTLS()
+	return server
+}
+
+// newClientCert creates a self-signed client certificate and returns it PEM encoded together with its key
+func newClientCert(t *testing.T) ([]byte, []byte, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pullrequest-operator"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), pool
+}
+
+func serverCABundle(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func newTLSTestPollers(server *httptest.Server, tlsOptions TLSOptions) map[string]PullrequestPoller {
+	transportOptions := TransportOptions{TLSOptions: tlsOptions}
+	return map[string]PullrequestPoller{
+		"github":    NewGithubPoller(server.URL, "secret", transportOptions, "jquad", "microservice"),
+		"bitbucket": NewBitbucketPoller(server.URL+"/rest", "secret", transportOptions, "jquad", "microservice"),
+	}
+}
+
+func pollOne(t *testing.T, poller PullrequestPoller) (pullrequestv1alpha1.Branches, error) {
+	t.Helper()
+	branches, _, err := poller.Poll(context.Background(), "main", "")
+	if err == nil && branches.GetSize() != 1 {
+		t.Errorf("expected 1 branch, got %d", branches.GetSize())
+	}
+	return branches, err
+}
+
+func TestPollerCABundle(t *testing.T) {
+	server := newTLSServer(t, nil)
+	defer server.Close()
+
+	for name, poller := range newTLSTestPollers(server, TLSOptions{}) {
+		if _, err := pollOne(t, poller); err == nil {
+			t.Errorf("%s: expected an error for an untrusted certificate", name)
+		}
+	}
+	for name, poller := range newTLSTestPollers(server, TLSOptions{CABundle: serverCABundle(server)}) {
+		if _, err := pollOne(t, poller); err != nil {
+			t.Errorf("%s: expected the certificate to be verified with the CA bundle: %v", name, err)
+		}
+	}
+	for name, poller := range newTLSTestPollers(server, TLSOptions{InsecureSkipVerify: true}) {
+		if _, err := pollOne(t, poller); err != nil {
+			t.Errorf("%s: expected the certificate to be accepted: %v", name, err)
+		}
+	}
+}
+
+func TestPollerClientCert(t *testing.T) {
+	clientCert, clientKey, clientCAs := newClientCert(t)
+	server := newTLSServer(t, clientCAs)
+	defer server.Close()
+
+	for name, poller := range newTLSTestPollers(server, TLSOptions{CABundle: serverCABundle(server)}) {
+		if _, err := pollOne(t, poller); err == nil {
+			t.Errorf("%s: expected an error without client certificate", name)
+		}
+	}
+	for name, poller := range newTLSTestPollers(server, TLSOptions{CABundle: serverCABundle(server), ClientCert: clientCert, ClientKey: clientKey}) {
+		if _, err := pollOne(t, poller); err != nil {
+			t.Errorf("%s: expected the client certificate to be accepted: %v", name, err)
+		}
+	}
+}
+
+func TestTLSConfigInvalid(t *testing.T) {
+	if _, err := (TLSOptions{CABundle: []byte("not a certificate")}).TLSConfig(); err == nil {
+		t.Error("expected an error for an invalid CA bundle")
+	}
+	if _, err := (TLSOptions{ClientCert: []byte("not a certificate")}).TLSConfig(); err == nil {
+		t.Error("expected an error for a client certificate without key")
+	}
+}
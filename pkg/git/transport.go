@@ -0,0 +1,151 @@
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// timeout of a request to the git provider, if not specified
+	defaultRequestTimeout = 30 * time.Second
+	// transports which were not used for this duration are closed and removed from the cache
+	transportIdleTimeout = time.Hour
+)
+
+// TransportOptions configures the connection to the git provider
+type TransportOptions struct {
+	TLSOptions
+	// URL of the HTTP(S) proxy, if empty the proxy is taken from the environment, i.e. HTTPS_PROXY and NO_PROXY
+	Proxy string
+	// Timeout of a request, defaults to 30 seconds
+	Timeout time.Duration
+	// RateLimit receives the rate limit reported with the last response of the git provider, if set
+	RateLimit *RateLimit
+}
+
+type cachedTransport struct {
+	transport *http.Transport
+	lastUsed  time.Time
+}
+
+// transports are cached by their settings, so that objects with the same settings reuse connections, while objects
+// with different settings, e.g. insecureSkipVerify, never share a transport
+var transports = struct {
+	sync.Mutex
+	cache map[string]*cachedTransport
+}{cache: map[string]*cachedTransport{}}
+
+// getTransport returns the cached transport for the options, or creates a new one
+func getTransport(transportOptions TransportOptions) (*http.Transport, error) {
+	key, err := transportOptions.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	transports.Lock()
+	defer transports.Unlock()
+
+	now := time.Now()
+	for cachedKey, cached := range transports.cache {
+		if now.Sub(cached.lastUsed) > transportIdleTimeout {
+			cached.transport.CloseIdleConnections()
+			delete(transports.cache, cachedKey)
+		}
+	}
+
+	if cached, ok := transports.cache[key]; ok {
+		cached.lastUsed = now
+		return cached.transport, nil
+	}
+
+	transport, err := transportOptions.newTransport()
+	if err != nil {
+		return nil, err
+	}
+	transports.cache[key] = &cachedTransport{transport: transport, lastUsed: now}
+	return transport, nil
+}
+
+// newTransport creates a transport, which does not share any settings with http.DefaultTransport
+func (transportOptions TransportOptions) newTransport() (*http.Transport, error) {
+	tlsConfig, err := transportOptions.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if len(transportOptions.Proxy) > 0 {
+		proxyUrl, err := parseProxy(transportOptions.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyUrl)
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
+
+// Validate checks the CA bundle, the client certificate and the proxy, so that a misconfiguration is reported before
+// the first request
+func (transportOptions TransportOptions) Validate() error {
+	if _, err := transportOptions.TLSConfig(); err != nil {
+		return err
+	}
+	if len(transportOptions.Proxy) > 0 {
+		if _, err := parseProxy(transportOptions.Proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseProxy parses the URL of the proxy, which requires a scheme and a host
+func parseProxy(proxy string) (*url.URL, error) {
+	proxyUrl, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+	if len(proxyUrl.Scheme) == 0 || len(proxyUrl.Host) == 0 {
+		return nil, fmt.Errorf("invalid proxy %q: the scheme and the host must be set", proxy)
+	}
+	return proxyUrl, nil
+}
+
+func (transportOptions TransportOptions) timeout() time.Duration {
+	if transportOptions.Timeout > 0 {
+		return transportOptions.Timeout
+	}
+	return defaultRequestTimeout
+}
+
+// hash identifies the settings of the transport, the CA bundle and client certificate are part of it
+func (transportOptions TransportOptions) hash() (string, error) {
+	options, err := json.Marshal(struct {
+		TLSOptions TLSOptions
+		Proxy      string
+	}{transportOptions.TLSOptions, transportOptions.Proxy})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(options)
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,136 @@
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetTransportIsolatesSettings(t *testing.T) {
+	secure, err := getTransport(TransportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secureAgain, err := getTransport(TransportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secure != secureAgain {
+		t.Error("expected the transport to be reused for the same settings")
+	}
+
+	for name, transportOptions := range map[string]TransportOptions{
+		"insecure": {TLSOptions: TLSOptions{InsecureSkipVerify: true}},
+		"proxy":    {Proxy: "http://proxy.jquad.rocks:3128"},
+	} {
+		transport, err := getTransport(transportOptions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transport == secure {
+			t.Errorf("%s: expected a separate transport", name)
+		}
+	}
+	if secure.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected the secure transport to verify certificates")
+	}
+	if secure == http.DefaultTransport {
+		t.Error("expected the transport not to be the default transport")
+	}
+}
+
+func TestPollerMixedInsecureSkipVerify(t *testing.T) {
+	server := newTLSServer(t, nil)
+	defer server.Close()
+
+	// interleave secure and insecure pollers, as the reconciliations of different objects would
+	for i := 0; i < 2; i++ {
+		for name, poller := range newTLSTestPollers(server, TLSOptions{InsecureSkipVerify: true}) {
+			if _, err := pollOne(t, poller); err != nil {
+				t.Errorf("%s: expected the insecure poller to accept the certificate: %v", name, err)
+			}
+		}
+		for name, poller := range newTLSTestPollers(server, TLSOptions{}) {
+			if _, err := pollOne(t, poller); err == nil {
+				t.Errorf("%s: expected the secure poller to reject the certificate after an insecure poll", name)
+			}
+		}
+	}
+
+	if tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig; tlsConfig != nil && tlsConfig.InsecureSkipVerify {
+		t.Error("expected the default transport to verify certificates")
+	}
+	if _, err := http.Get(server.URL); err == nil {
+		t.Error("expected the default client to reject the certificate")
+	}
+}
+
+func TestPollerProxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the proxy answers the request itself instead of forwarding it
+		atomic.AddInt32(&proxied, 1)
+		w.Write([]byte(giteaPullsResponse))
+	}))
+	defer proxy.Close()
+
+	poller := NewGiteaPoller("http://gitea.jquad.rocks", "", TransportOptions{Proxy: proxy.URL}, "jquad", "microservice")
+	branches, _, err := poller.Poll(context.Background(), "main", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branches.GetSize() != 2 || atomic.LoadInt32(&proxied) != 1 {
+		t.Errorf("expected the request to be sent through the proxy, got %d branches and %d proxied requests", branches.GetSize(), proxied)
+	}
+}
+
+func TestPollerCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the git provider hangs until the request is aborted
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	for name, poller := range map[string]PullrequestPoller{
+		"bitbucket": NewBitbucketPoller(server.URL+"/rest", "secret", TransportOptions{}, "jquad", "microservice"),
+		"github":    NewGithubPoller(server.URL+"/", "secret", TransportOptions{}, "jquad", "microservice"),
+		"gitea":     NewGiteaPoller(server.URL, "secret", TransportOptions{}, "jquad", "microservice"),
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		_, _, err := poller.Poll(ctx, "main", "")
+		cancel()
+		if err == nil {
+			t.Errorf("%s: expected the poll to be aborted", name)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: expected the poll to be aborted with the context, took %s", name, elapsed)
+		}
+	}
+}
+
+func TestTransportOptionsValidate(t *testing.T) {
+	for name, test := range map[string]struct {
+		transportOptions TransportOptions
+		valid            bool
+	}{
+		"default":            {TransportOptions{}, true},
+		"proxy":              {TransportOptions{Proxy: "http://proxy.example.com:3128"}, true},
+		"proxy without host": {TransportOptions{Proxy: "proxy.example.com:3128"}, false},
+		"invalid proxy":      {TransportOptions{Proxy: "http://proxy example.com"}, false},
+		"invalid CA bundle":  {TransportOptions{TLSOptions: TLSOptions{CABundle: []byte("not a certificate")}}, false},
+		"client cert only":   {TransportOptions{TLSOptions: TLSOptions{ClientCert: []byte("not a certificate")}}, false},
+	} {
+		if err := test.transportOptions.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", name, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+	gitApi "github.com/jquad-group/pullrequest-operator/pkg/git"
+)
+
+const (
+	// Key of the HMAC secret in the secret referenced by the PullRequest's git provider
+	SECRET_WEBHOOK_KEY = "webhookSecret"
+
+	// GitHub sends the event type and the signature in these headers
+	GITHUB_EVENT_HEADER     = "X-GitHub-Event"
+	GITHUB_SIGNATURE_HEADER = "X-Hub-Signature-256"
+
+	// Bitbucket Server sends the event key and the signature in these headers
+	BITBUCKET_EVENT_HEADER     = "X-Event-Key"
+	BITBUCKET_SIGNATURE_HEADER = "X-Hub-Signature"
+
+	// GitHub payloads are capped at 25 MB
+	maxPayloadSize = 25 << 20
+)
+
+// Receiver accepts GitHub pull_request and Bitbucket Server pr:* webhook events and triggers the reconciliation of
+// every PullRequest watching the repository of the event, whose webhook secret verifies the event's signature.
+type Receiver struct {
+	Client client.Client
+
+	// Address the webhook server binds to
+	Addr string
+
+	// Events receives a generic event for each PullRequest to reconcile
+	Events chan<- event.GenericEvent
+}
+
+type githubPayload struct {
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+type bitbucketPayload struct {
+	PullRequest struct {
+		ToRef struct {
+			Repository struct {
+				Slug    string `json:"slug"`
+				Project struct {
+					Key string `json:"key"`
+				} `json:"project"`
+			} `json:"repository"`
+		} `json:"toRef"`
+	} `json:"pullRequest"`
+}
+
+// Start runs the webhook server until the context is cancelled
+func (r *Receiver) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              r.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errChan:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	log := log.FromContext(req.Context()).WithName("webhook")
+
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(req.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "unable to read the payload", http.StatusBadRequest)
+		return
+	}
+
+	var provider, owner, repository, signature string
+	switch {
+	case req.Header.Get(GITHUB_EVENT_HEADER) != "":
+		if req.Header.Get(GITHUB_EVENT_HEADER) != "pull_request" {
+			// e.g. ping
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var event githubPayload
+		if err := json.Unmarshal(payload, &event); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		provider = pipelinev1alpha1.GITHUB_PROVIDER_NAME
+		owner = event.Repository.Owner.Login
+		repository = event.Repository.Name
+		signature = req.Header.Get(GITHUB_SIGNATURE_HEADER)
+	case req.Header.Get(BITBUCKET_EVENT_HEADER) != "":
+		if !strings.HasPrefix(req.Header.Get(BITBUCKET_EVENT_HEADER), "pr:") {
+			// e.g. diagnostics:ping
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var event bitbucketPayload
+		if err := json.Unmarshal(payload, &event); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		provider = pipelinev1alpha1.BITBUCKET_PROVIDER_NAME
+		owner = event.PullRequest.ToRef.Repository.Project.Key
+		repository = event.PullRequest.ToRef.Repository.Slug
+		signature = req.Header.Get(BITBUCKET_SIGNATURE_HEADER)
+	default:
+		http.Error(w, "unsupported event", http.StatusBadRequest)
+		return
+	}
+
+	var pullrequests pipelinev1alpha1.PullRequestList
+	if err := r.Client.List(req.Context(), &pullrequests); err != nil {
+		log.Error(err, "unable to list PullRequests")
+		http.Error(w, "unable to list PullRequests", http.StatusInternalServerError)
+		return
+	}
+
+	triggered := 0
+	for i := range pullrequests.Items {
+		pullrequest := &pullrequests.Items[i]
+		if !watches(pullrequest, provider, owner, repository) {
+			continue
+		}
+		if !r.verify(req.Context(), pullrequest, payload, signature) {
+			continue
+		}
+		// the pull requests of the repository are listed again, instead of being served from the shared poll
+		gitApi.ExpireRepository(gitApi.RepositoryKey(pullrequest.Spec.GitProvider))
+		select {
+		case r.Events <- event.GenericEvent{Object: pullrequest}:
+			triggered++
+		default:
+			log.Info("dropped webhook event, the reconcile queue is full", "pullrequest", types.NamespacedName{Name: pullrequest.Name, Namespace: pullrequest.Namespace})
+		}
+	}
+
+	if triggered == 0 {
+		// do not reveal whether a PullRequest watches the repository
+		http.Error(w, "no PullRequest with a matching webhook secret", http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// watches returns true if the PullRequest polls the repository of the event
+func watches(pullrequest *pipelinev1alpha1.PullRequest, provider string, owner string, repository string) bool {
+	gitProvider := pullrequest.Spec.GitProvider
+	if gitProvider.Provider != provider {
+		return false
+	}
+	switch provider {
+	case pipelinev1alpha1.GITHUB_PROVIDER_NAME:
+		return strings.EqualFold(gitProvider.Github.Owner, owner) && strings.EqualFold(gitProvider.Github.Repository, repository)
+	case pipelinev1alpha1.BITBUCKET_PROVIDER_NAME:
+		return strings.EqualFold(gitProvider.Bitbucket.Project, owner) && strings.EqualFold(gitProvider.Bitbucket.Repository, repository)
+	}
+	return false
+}
+
+// verify checks the HMAC-SHA256 signature of the payload with the webhook secret of the PullRequest
+func (r *Receiver) verify(ctx context.Context, pullrequest *pipelinev1alpha1.PullRequest, payload []byte, signature string) bool {
+	if len(pullrequest.Spec.GitProvider.SecretRef) == 0 {
+		return false
+	}
+	secret := &v1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: pullrequest.Spec.GitProvider.SecretRef, Namespace: pullrequest.Namespace}, secret); err != nil {
+		return false
+	}
+	webhookSecret := secret.Data[SECRET_WEBHOOK_KEY]
+	if len(webhookSecret) == 0 {
+		return false
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, webhookSecret)
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	pipelinev1alpha1 "github.com/jquad-group/pullrequest-operator/api/v1alpha1"
+)
+
+func newTestReceiver(t *testing.T, objects ...runtime.Object) (*Receiver, chan event.GenericEvent) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := pipelinev1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan event.GenericEvent, 10)
+	return &Receiver{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		Events: events,
+	}, events
+}
+
+func newSecret(name string, webhookSecret string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data:       map[string][]byte{SECRET_WEBHOOK_KEY: []byte(webhookSecret)},
+	}
+}
+
+func newPullRequest(name string, secretRef string, gitProvider pipelinev1alpha1.GitProvider) *pipelinev1alpha1.PullRequest {
+	gitProvider.SecretRef = secretRef
+	return &pipelinev1alpha1.PullRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: pipelinev1alpha1.PullRequestSpec{
+			TargetBranch: pipelinev1alpha1.TargetBranch{Name: "main"},
+			GitProvider:  gitProvider,
+		},
+	}
+}
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func readPayload(t *testing.T, name string) []byte {
+	payload, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func post(receiver *Receiver, headers map[string]string, payload []byte) int {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func drain(events chan event.GenericEvent) []string {
+	var names []string
+	for {
+		select {
+		case e := <-events:
+			names = append(names, e.Object.GetName())
+		default:
+			return names
+		}
+	}
+}
+
+func TestReceiverGithubPullRequest(t *testing.T) {
+	github := pipelinev1alpha1.GitProvider{
+		Provider: pipelinev1alpha1.GITHUB_PROVIDER_NAME,
+		Github:   pipelinev1alpha1.Github{Owner: "jquad-group", Repository: "microservice"},
+	}
+	otherRepo := pipelinev1alpha1.GitProvider{
+		Provider: pipelinev1alpha1.GITHUB_PROVIDER_NAME,
+		Github:   pipelinev1alpha1.Github{Owner: "jquad-group", Repository: "other"},
+	}
+	receiver, events := newTestReceiver(t,
+		newSecret("github-secret", "s3cr3t"),
+		newSecret("other-secret", "different"),
+		newPullRequest("watching", "github-secret", github),
+		newPullRequest("wrong-secret", "other-secret", github),
+		newPullRequest("other-repo", "github-secret", otherRepo),
+	)
+	payload := readPayload(t, "github-pull-request.json")
+
+	code := post(receiver, map[string]string{
+		GITHUB_EVENT_HEADER:     "pull_request",
+		GITHUB_SIGNATURE_HEADER: sign("s3cr3t", payload),
+	}, payload)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, code)
+	}
+	if names := drain(events); len(names) != 1 || names[0] != "watching" {
+		t.Errorf("expected only the watching PullRequest to be reconciled, got %v", names)
+	}
+
+	code = post(receiver, map[string]string{
+		GITHUB_EVENT_HEADER:     "pull_request",
+		GITHUB_SIGNATURE_HEADER: sign("wrong", payload),
+	}, payload)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("expected %d for an invalid signature, got %d", http.StatusUnauthorized, code)
+	}
+	if names := drain(events); len(names) != 0 {
+		t.Errorf("expected no reconciliation for an invalid signature, got %v", names)
+	}
+
+	code = post(receiver, map[string]string{GITHUB_EVENT_HEADER: "ping"}, []byte(`{"zen": "Keep it logically awesome."}`))
+	if code != http.StatusOK {
+		t.Fatalf("expected %d for a ping, got %d", http.StatusOK, code)
+	}
+}
+
+func TestReceiverBitbucketPullRequest(t *testing.T) {
+	bitbucket := pipelinev1alpha1.GitProvider{
+		Provider:  pipelinev1alpha1.BITBUCKET_PROVIDER_NAME,
+		Bitbucket: pipelinev1alpha1.Bitbucket{Project: "proj", Repository: "microservice"},
+	}
+	receiver, events := newTestReceiver(t,
+		newSecret("bitbucket-secret", "s3cr3t"),
+		newPullRequest("watching", "bitbucket-secret", bitbucket),
+	)
+	payload := readPayload(t, "bitbucket-pr-opened.json")
+
+	code := post(receiver, map[string]string{
+		BITBUCKET_EVENT_HEADER:     "pr:opened",
+		BITBUCKET_SIGNATURE_HEADER: sign("s3cr3t", payload),
+	}, payload)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, code)
+	}
+	if names := drain(events); len(names) != 1 || names[0] != "watching" {
+		t.Errorf("expected the watching PullRequest to be reconciled, got %v", names)
+	}
+
+	code = post(receiver, map[string]string{BITBUCKET_EVENT_HEADER: "pr:opened"}, payload)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("expected %d for a missing signature, got %d", http.StatusUnauthorized, code)
+	}
+
+	code = post(receiver, map[string]string{BITBUCKET_EVENT_HEADER: "diagnostics:ping"}, []byte(`{"test": true}`))
+	if code != http.StatusOK {
+		t.Fatalf("expected %d for a ping, got %d", http.StatusOK, code)
+	}
+}